@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/root4loot/goutils/log"
+	"github.com/root4loot/rescope/pkg/common"
+	"github.com/root4loot/rescope/pkg/rescope"
+)
+
+const authUsage = `
+Usage:
+  rescope auth check [options]
+
+Verifies each configured token with the platform it belongs to, and reports
+whether it is valid, invalid or expired along with the account name. Tokens
+are read from the command line, RESCOPE_* environment variables and the
+config file, as for rescope. With --profile, the tokens of each profile are
+checked. Exits with 5 if a token is rejected.
+
+OUTPUT:
+  -oJ, --output-json          output JSON
+
+AUTHORIZATION:
+  --auth-bugcrowd             bugcrowd secret    (_bugcrowd_session=cookie.value) [Optional]
+  --auth-hackerone            hackerone secret   (Authorization bearer token) [Optional]
+  --auth-yeswehack            yeswehack secret   (Authorization bearer token) [Optional]
+  --auth-intigriti            intigriti secret   (see https://app.intigriti.com/researcher/personal-access-tokens) [Optional]
+  --user-<platform>           account name of a platform's token (hackerone, bugcrowd, intigriti, yeswehack) [Optional]
+  --profile                   comma separated credential profiles of the config file to check
+
+GENERAL:
+      --proxy                 proxy to use for requests (e.g. 127.0.0.1:8080)
+      --base-url-<platform>   send requests for a platform (hackerone, bugcrowd, intigriti, yeswehack) to this base URL instead
+      --config                config file (default: ~/.config/rescope/config.yaml)
+      --debug                 enable debug mode (tokens, cookies and CSRF values are redacted)
+      --trace                 write requests and responses to the given file, with credentials redacted
+`
+
+// authCLI holds the options of the auth check command
+type authCLI struct {
+	CLI
+	OutputJson bool
+}
+
+func parseAuthCLI(args []string) (*authCLI, error) {
+	var help bool
+	cli := authCLI{}
+
+	fs := flag.NewFlagSet("rescope auth check", flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprint(os.Stdout, authUsage) }
+	cli.addSharedFlags(fs)
+	fs.BoolVar(&cli.OutputJson, "oJ", false, "")
+	fs.BoolVar(&cli.OutputJson, "output-json", false, "")
+	fs.BoolVar(&help, "h", false, "")
+	fs.BoolVar(&help, "help", false, "")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if _, err := cli.loadConfig(fs); err != nil {
+		return nil, err
+	}
+
+	if help {
+		fmt.Fprint(os.Stdout, authUsage)
+		os.Exit(0)
+	}
+
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+
+	return &cli, nil
+}
+
+// runAuth runs the auth command and returns its exit code
+func runAuth(args []string) int {
+	if len(args) == 0 || args[0] != "check" {
+		fmt.Fprint(os.Stdout, authUsage)
+		if len(args) > 0 && (args[0] == "-h" || args[0] == "--help") {
+			return ExitOK
+		}
+		return ExitUsage
+	}
+
+	cli, err := parseAuthCLI(args[1:])
+	if err != nil {
+		log.Error("Failed to parse CLI arguments", "error", err)
+		return ExitUsage
+	}
+
+	profiles, err := cli.profileOptions()
+	if err != nil {
+		log.Error("Failed to set up options", "error", err)
+		return ExitError
+	}
+
+	var statuses []common.AuthStatus
+	for _, opts := range profiles {
+		statuses = append(statuses, rescope.CheckAuth(opts)...)
+	}
+
+	if len(statuses) == 0 {
+		log.Error("No tokens configured. Set --auth-<platform>, RESCOPE_AUTH_<PLATFORM> or auth-<platform> in the config file")
+		return ExitAuthRequired
+	}
+
+	var output string
+	if cli.OutputJson {
+		output, err = getAuthJsonOutput(statuses)
+		if err != nil {
+			log.Error("Failed to write output", "error", err)
+			return ExitError
+		}
+	} else {
+		output = getAuthTableOutput(statuses)
+	}
+	fmt.Fprintln(os.Stdout, output)
+
+	return authExitCode(statuses)
+}
+
+// authExitCode returns ExitAuthInvalid if a token was rejected, ExitError if
+// one couldn't be checked, and ExitOK if all are valid
+func authExitCode(statuses []common.AuthStatus) int {
+	code := ExitOK
+	for _, status := range statuses {
+		switch status.Status {
+		case common.AuthInvalid, common.AuthExpired:
+			return ExitAuthInvalid
+		case common.AuthError:
+			code = ExitError
+		}
+	}
+	return code
+}
+
+func getAuthJsonOutput(statuses []common.AuthStatus) (string, error) {
+	jsonData, err := json.MarshalIndent(statuses, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize credential statuses to JSON: %w", err)
+	}
+	return string(jsonData), nil
+}
+
+func getAuthTableOutput(statuses []common.AuthStatus) string {
+	var builder strings.Builder
+	w := tabwriter.NewWriter(&builder, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PLATFORM\tPROFILE\tACCOUNT\tEXPIRES\tSTATUS")
+
+	for _, status := range statuses {
+		detail := status.Status
+		if status.Error != "" {
+			detail += ": " + status.Error
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", status.Platform, orDash(status.Profile), orDash(status.Account), orDash(status.ExpiresAt), detail)
+	}
+
+	w.Flush()
+	return strings.TrimRight(builder.String(), "\n")
+}
+
+// orDash returns s, or "-" if it is empty
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/root4loot/goutils/log"
+	"github.com/root4loot/rescope/config"
+	"github.com/root4loot/rescope/pkg/common"
+	"github.com/root4loot/rescope/pkg/normalize"
+)
+
+// burpOptions controls the Burp output
+type burpOptions struct {
+	Legacy  bool   // simple URL prefix scope instead of advanced mode
+	Project string // project options file to merge the scope into
+}
+
+// getBurpOutput returns the scope as Burp project options. If a project
+// options file is given, only its target.scope is replaced.
+func getBurpOutput(result *common.Result, opts burpOptions) (string, error) {
+	var scope interface{}
+	if opts.Legacy {
+		scope = burpSimpleScope(result)
+	} else {
+		scope = burpAdvancedScope(result)
+	}
+
+	if opts.Project != "" {
+		return mergeBurpProject(opts.Project, scope)
+	}
+
+	output, err := json.MarshalIndent(scope, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize Burp scope to JSON: %w", err)
+	}
+
+	return string(output), nil
+}
+
+func burpAdvancedScope(result *common.Result) config.BurpConfig {
+	var scope config.BurpConfig
+	scope.Target.Scope.AdvancedMode = true
+	scope.Target.Scope.Include = []config.BurpInclude{}
+	scope.Target.Scope.Exclude = []config.BurpExclude{}
+
+	for _, entry := range parseScopeEntries(result.InScope) {
+		protocol, host, port, file := burpRule(entry)
+		scope.Target.Scope.Include = append(scope.Target.Scope.Include, config.BurpInclude{
+			Enabled:  true,
+			Protocol: protocol,
+			Host:     host,
+			Port:     port,
+			File:     file,
+		})
+	}
+
+	for _, entry := range parseScopeEntries(result.OutScope) {
+		protocol, host, port, file := burpRule(entry)
+		scope.Target.Scope.Exclude = append(scope.Target.Scope.Exclude, config.BurpExclude{
+			Enabled:  true,
+			Protocol: protocol,
+			Host:     host,
+			Port:     port,
+			File:     file,
+		})
+	}
+
+	return scope
+}
+
+// burpRule returns the advanced mode fields for entry. Hosts and paths are
+// regexes; IPs and CIDRs are passed as is, which Burp accepts as IP ranges.
+func burpRule(entry normalize.Entry) (protocol, host, port, file string) {
+	protocol = entry.Scheme
+	if protocol == "" {
+		protocol = "any"
+	}
+
+	switch entry.Kind {
+	case normalize.KindIP, normalize.KindCIDR:
+		host = entry.Host
+	default:
+		host = "^" + normalize.HostRegex(entry.Host) + "$"
+	}
+
+	if entry.Port != "" {
+		port = "^" + entry.Port + "$"
+	}
+
+	if entry.Path != "" {
+		file = "^" + strings.ReplaceAll(regexp.QuoteMeta(entry.Path), `\*`, ".*") + ".*"
+	}
+
+	return protocol, host, port, file
+}
+
+func burpSimpleScope(result *common.Result) config.BurpSimpleConfig {
+	var scope config.BurpSimpleConfig
+	scope.Target.Scope.Include = []config.BurpPrefix{}
+	scope.Target.Scope.Exclude = []config.BurpPrefix{}
+
+	for _, entry := range parseScopeEntries(result.InScope) {
+		scope.Target.Scope.Include = append(scope.Target.Scope.Include, burpPrefixes(entry)...)
+	}
+
+	for _, entry := range parseScopeEntries(result.OutScope) {
+		scope.Target.Scope.Exclude = append(scope.Target.Scope.Exclude, burpPrefixes(entry)...)
+	}
+
+	return scope
+}
+
+// burpPrefixes returns the simple mode prefixes for entry. Entries without a
+// scheme get a prefix for both http and https. Leading wildcards are matched
+// with "include subdomains", which also matches the parent domain.
+func burpPrefixes(entry normalize.Entry) []config.BurpPrefix {
+	includeSubdomains := false
+	host := entry.Host
+
+	switch {
+	case entry.Kind == normalize.KindCIDR:
+		log.Warn("Skipping CIDR in Burp legacy scope, use --expand-ip-ranges to include its IPs", "entry", entry.String())
+		return nil
+	case strings.HasPrefix(host, "*.") && !strings.Contains(host[2:], "*"):
+		includeSubdomains = true
+		host = host[2:]
+	case strings.Contains(host, "*"):
+		log.Warn("Skipping wildcard not expressible as a Burp URL prefix", "entry", entry.String())
+		return nil
+	}
+
+	if entry.Port != "" {
+		host += ":" + entry.Port
+	}
+
+	schemes := []string{entry.Scheme}
+	if entry.Scheme == "" {
+		schemes = []string{"http", "https"}
+	}
+
+	var prefixes []config.BurpPrefix
+	for _, scheme := range schemes {
+		prefixes = append(prefixes, config.BurpPrefix{
+			Enabled:           true,
+			IncludeSubdomains: includeSubdomains,
+			Prefix:            scheme + "://" + host + entry.Path,
+		})
+	}
+	return prefixes
+}
+
+// mergeBurpProject replaces target.scope in the project options file with
+// scope, keeping all other settings and their order
+func mergeBurpProject(file string, scope interface{}) (string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to read Burp project options: %w", err)
+	}
+
+	project, err := decodeObject(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse Burp project options: %w", err)
+	}
+
+	// the generated scope is wrapped in {"target":{"scope":...}}
+	generated, err := json.Marshal(scope)
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize Burp scope to JSON: %w", err)
+	}
+	wrapper, err := decodeObject(generated)
+	if err != nil {
+		return "", err
+	}
+	target, err := decodeObject(wrapper.get("target"))
+	if err != nil {
+		return "", err
+	}
+
+	projectTarget := orderedObject{}
+	if raw := project.get("target"); raw != nil {
+		if projectTarget, err = decodeObject(raw); err != nil {
+			return "", fmt.Errorf("failed to parse Burp project options target: %w", err)
+		}
+	}
+	projectTarget = projectTarget.set("scope", target.get("scope"))
+
+	targetData, err := json.Marshal(projectTarget)
+	if err != nil {
+		return "", err
+	}
+	project = project.set("target", targetData)
+
+	output, err := json.MarshalIndent(project, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize Burp project options to JSON: %w", err)
+	}
+
+	return string(output), nil
+}
+
+// orderedObject is a JSON object that keeps the order of its keys
+type orderedObject []orderedField
+
+type orderedField struct {
+	Key   string
+	Value json.RawMessage
+}
+
+func decodeObject(data []byte) (orderedObject, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '{' {
+		return nil, fmt.Errorf("expected a JSON object")
+	}
+
+	object := orderedObject{}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+		object = append(object, orderedField{Key: token.(string), Value: value})
+	}
+
+	return object, nil
+}
+
+func (o orderedObject) get(key string) json.RawMessage {
+	for _, field := range o {
+		if field.Key == key {
+			return field.Value
+		}
+	}
+	return nil
+}
+
+// set replaces the value of key, or appends it if missing
+func (o orderedObject) set(key string, value json.RawMessage) orderedObject {
+	for i, field := range o {
+		if field.Key == key {
+			o[i].Value = value
+			return o
+		}
+	}
+	return append(o, orderedField{Key: key, Value: value})
+}
+
+func (o orderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, field := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(field.Key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(field.Value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
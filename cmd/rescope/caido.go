@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/root4loot/goutils/log"
+	"github.com/root4loot/goutils/sliceutil"
+	"github.com/root4loot/rescope/config"
+	"github.com/root4loot/rescope/pkg/common"
+	"github.com/root4loot/rescope/pkg/normalize"
+)
+
+func getCaidoOutput(result *common.Result) (string, error) {
+	scope := config.CaidoScope{
+		Name:      result.ProgramDetails.ProgramName,
+		Allowlist: []string{},
+		Denylist:  []string{},
+	}
+
+	if scope.Name == "" {
+		scope.Name = AppName
+	}
+
+	for _, entry := range parseScopeEntries(result.InScope) {
+		scope.Allowlist = appendCaidoGlob(scope.Allowlist, entry, true)
+	}
+
+	for _, entry := range parseScopeEntries(result.OutScope) {
+		scope.Denylist = appendCaidoGlob(scope.Denylist, entry, false)
+	}
+
+	output, err := json.MarshalIndent(scope, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize Caido scope to JSON: %w", err)
+	}
+
+	return string(output), nil
+}
+
+// appendCaidoGlob translates a scope entry to a Caido host glob. Caido scope
+// rules only match hosts, so ports and paths are dropped for includes, which
+// widens them, while excludes carrying a port or path are skipped rather
+// than excluding the whole host.
+func appendCaidoGlob(globs []string, entry normalize.Entry, isInclude bool) []string {
+	switch entry.Kind {
+	case normalize.KindIP:
+		return sliceutil.AppendUnique(globs, strings.Trim(entry.Host, "[]"))
+	case normalize.KindCIDR:
+		glob, ok := cidrToGlob(entry.Host)
+		if !ok {
+			log.Warn("Skipping CIDR not expressible as a Caido glob, use --expand-ip-ranges to include its IPs", "entry", entry.Raw)
+			return globs
+		}
+		return sliceutil.AppendUnique(globs, glob)
+	}
+
+	if !isInclude && (entry.Port != "" || entry.Path != "") {
+		log.Warn("Skipping exclude with port or path, Caido scope only matches hosts", "entry", entry.Raw)
+		return globs
+	}
+
+	return sliceutil.AppendUnique(globs, strings.Trim(entry.Host, "[]"))
+}
+
+// cidrToGlob converts IPv4 CIDRs on octet boundaries to globs such as 10.0.*.*
+func cidrToGlob(cidr string) (string, bool) {
+	prefix, err := netip.ParsePrefix(cidr)
+	if err != nil || !prefix.Addr().Is4() || prefix.Bits()%8 != 0 {
+		return "", false
+	}
+
+	octets := strings.Split(prefix.Masked().Addr().String(), ".")
+	for i := prefix.Bits() / 8; i < len(octets); i++ {
+		octets[i] = "*"
+	}
+
+	return strings.Join(octets, "."), true
+}
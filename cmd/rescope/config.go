@@ -0,0 +1,376 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/root4loot/goutils/log"
+	"github.com/root4loot/rescope/pkg/redact"
+	"gopkg.in/yaml.v3"
+)
+
+// envPrefix is the prefix of the environment variables setting options, e.g.
+// RESCOPE_AUTH_INTIGRITI for --auth-intigriti
+const envPrefix = "RESCOPE_"
+
+const configUsage = `
+Usage:
+  rescope config show [options]
+
+Prints the effective configuration, with secrets redacted. Options are read
+from the command line, then from RESCOPE_* environment variables (e.g.
+RESCOPE_AUTH_INTIGRITI for --auth-intigriti), then from the config file
+(default: ~/.config/rescope/config.yaml, or the file set with --config or
+RESCOPE_CONFIG). The file maps long option names to their values:
+
+  auth-intigriti: <token>
+  concurrency: 10
+  output-burp: true
+  zap-tech-exclude: [Db, Language.PHP]
+  profiles:
+    alice:
+      auth-hackerone: <token>
+      user-hackerone: alice
+
+Each option is annotated with where it was set.
+`
+
+// configPath returns the config file to read, and whether it was given
+// explicitly rather than being the default location
+func (cli *CLI) configPath() (string, bool) {
+	if cli.ConfigFile != "" {
+		return cli.ConfigFile, true
+	}
+	if path, ok := os.LookupEnv(envPrefix + "CONFIG"); ok && path != "" {
+		return path, true
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", false
+	}
+	return filepath.Join(dir, AppName, "config.yaml"), false
+}
+
+// configFile holds the options and credential profiles of a config file
+type configFile struct {
+	Options  map[string]string
+	Profiles map[string]map[string]string // credential options by profile name
+}
+
+// loadConfig sets the options of fs not given on the command line from the
+// environment, then from the config file, and keeps the profiles of the
+// file. It returns where each option that is set came from.
+func (cli *CLI) loadConfig(fs *flag.FlagSet) (map[string]string, error) {
+	path, explicit := cli.configPath()
+
+	file, err := readConfigFile(path, explicit)
+	if err != nil {
+		return nil, err
+	}
+
+	known := knownOptions()
+	for name := range file.Options {
+		if !known[name] {
+			log.Warn("Unknown option in config file, ignoring", "file", path, "option", name)
+		}
+	}
+
+	sources, err := applyConfig(fs, file.Options, os.LookupEnv)
+	if err != nil {
+		return nil, err
+	}
+
+	cli.profiles = file.Profiles
+	cli.sources = sources
+	return sources, nil
+}
+
+// readConfigFile reads the options and profiles of a config file. A missing
+// file is only an error if it was given explicitly. List values are joined
+// with commas, as on the command line.
+func readConfigFile(path string, explicit bool) (*configFile, error) {
+	file := &configFile{Options: map[string]string{}, Profiles: map[string]map[string]string{}}
+	if path == "" {
+		return file, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !explicit {
+		return file, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	file.Options, err = configOptions(raw, "")
+	if err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	if profiles, ok := raw["profiles"]; ok {
+		profileMap, ok := profiles.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("config file %s: profiles must map profile names to credentials", path)
+		}
+
+		for name, profile := range profileMap {
+			credentials, ok := profile.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("config file %s: profile %s must map options to values", path, name)
+			}
+			if file.Profiles[name], err = configOptions(credentials, name); err != nil {
+				return nil, fmt.Errorf("config file %s: %w", path, err)
+			}
+		}
+	}
+
+	log.Debug("Read config file", "file", path, "options", len(file.Options), "profiles", len(file.Profiles))
+	return file, nil
+}
+
+// configOptions converts the values of a config file mapping to option
+// values. The options of a profile must be credentials.
+func configOptions(raw map[string]interface{}, profile string) (map[string]string, error) {
+	options := make(map[string]string)
+	for name, value := range raw {
+		if profile == "" && name == "profiles" {
+			continue
+		}
+		if profile != "" && !isCredential(name) {
+			return nil, fmt.Errorf("profile %s: %s is not a credential option (auth-<platform> or user-<platform>)", profile, name)
+		}
+
+		switch v := value.(type) {
+		case nil:
+			continue
+		case []interface{}:
+			var items []string
+			for _, item := range v {
+				items = append(items, fmt.Sprint(item))
+			}
+			options[name] = strings.Join(items, ",")
+		case map[string]interface{}:
+			return nil, fmt.Errorf("option %s must be a value or list", name)
+		default:
+			options[name] = fmt.Sprint(v)
+		}
+	}
+	return options, nil
+}
+
+// applyConfig sets the options of fs that weren't given on the command line
+// from the environment, then from the config file. Output formats set by a
+// source replace those of the sources after it rather than adding to them.
+// It returns the source of each option that is set: flag, env or file.
+func applyConfig(fs *flag.FlagSet, file map[string]string, lookupEnv func(string) (string, bool)) (map[string]string, error) {
+	sources := make(map[string]string)
+	set := make(map[flag.Value]bool)
+	formatSet := false
+
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Value] = true
+		formatSet = formatSet || isOutputFormat(f)
+	})
+
+	names := optionNames(fs)
+	for _, name := range names {
+		if set[fs.Lookup(name).Value] {
+			sources[name] = "flag"
+		}
+	}
+
+	layers := []struct {
+		source string
+		lookup func(name string) (string, bool)
+	}{
+		{"env", func(name string) (string, bool) { return lookupEnv(envName(name)) }},
+		{"file", func(name string) (string, bool) { value, ok := file[name]; return value, ok }},
+	}
+
+	for _, layer := range layers {
+		layerFormatSet := false
+		for _, name := range names {
+			f := fs.Lookup(name)
+			if set[f.Value] || (formatSet && isOutputFormat(f)) {
+				continue
+			}
+
+			value, ok := layer.lookup(name)
+			if !ok {
+				continue
+			}
+
+			if err := fs.Set(name, value); err != nil {
+				return nil, fmt.Errorf("invalid value for %s from %s: %w", name, layer.source, err)
+			}
+			set[f.Value] = true
+			sources[name] = layer.source
+			layerFormatSet = layerFormatSet || isOutputFormat(f)
+		}
+		formatSet = formatSet || layerFormatSet
+	}
+
+	return sources, nil
+}
+
+// optionNames returns the configurable options of fs in lexical order. Of
+// flags sharing a value, such as -oB and --output-burp, only the longest name
+// is returned.
+func optionNames(fs *flag.FlagSet) []string {
+	longest := make(map[flag.Value]string)
+	fs.VisitAll(func(f *flag.Flag) {
+		if len(f.Name) > len(longest[f.Value]) {
+			longest[f.Value] = f.Name
+		}
+	})
+
+	var names []string
+	fs.VisitAll(func(f *flag.Flag) {
+		if longest[f.Value] == f.Name && f.Name != "config" {
+			names = append(names, f.Name)
+		}
+	})
+	return names
+}
+
+// knownOptions returns the names of the options of rescope and its commands
+func knownOptions() map[string]bool {
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	(&CLI{}).addFlags(fs)
+
+	programs := flag.NewFlagSet("", flag.ContinueOnError)
+	(&programsCLI{}).addProgramsFlags(programs)
+
+	known := make(map[string]bool)
+	for _, name := range append(optionNames(fs), optionNames(programs)...) {
+		known[name] = true
+	}
+	return known
+}
+
+// envName returns the environment variable setting an option
+func envName(option string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(option, "-", "_"))
+}
+
+// isOutputFormat reports whether f selects an output format
+func isOutputFormat(f *flag.Flag) bool {
+	_, ok := f.Value.(*outputFlag)
+	return ok || f.Name == "oTpl" || f.Name == "output-template" || f.Name == "output-template-file"
+}
+
+// isSecret reports whether an option holds a token
+func isSecret(option string) bool {
+	return strings.HasPrefix(option, "auth-")
+}
+
+// isCredential reports whether an option belongs in a credential profile
+func isCredential(option string) bool {
+	return isSecret(option) || strings.HasPrefix(option, "user-")
+}
+
+// getConfigOutput renders the effective options of fs and the credential
+// profiles as a config file, with secrets redacted and each option set
+// annotated with its source
+func getConfigOutput(fs *flag.FlagSet, sources map[string]string, profiles map[string]map[string]string) (string, error) {
+	mapping := &yaml.Node{Kind: yaml.MappingNode}
+
+	for _, name := range optionNames(fs) {
+		f := fs.Lookup(name)
+
+		var value interface{} = f.Value.String()
+		if getter, ok := f.Value.(flag.Getter); ok {
+			value = getter.Get()
+		} else if enabled, err := strconv.ParseBool(f.Value.String()); err == nil {
+			value = enabled
+		}
+		if isSecret(name) {
+			value = redact.Secret(f.Value.String())
+		}
+
+		var node yaml.Node
+		if err := node.Encode(value); err != nil {
+			return "", fmt.Errorf("failed to render option %s: %w", name, err)
+		}
+		if source, ok := sources[name]; ok {
+			node.LineComment = source
+			if source == "env" {
+				node.LineComment += " " + envName(name)
+			}
+		}
+
+		mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: name}, &node)
+	}
+
+	if len(profiles) > 0 {
+		redacted := make(map[string]map[string]string)
+		for name, profile := range profiles {
+			redacted[name] = make(map[string]string)
+			for option, value := range profile {
+				if isSecret(option) {
+					value = redact.Secret(value)
+				}
+				redacted[name][option] = value
+			}
+		}
+
+		var node yaml.Node
+		if err := node.Encode(redacted); err != nil {
+			return "", fmt.Errorf("failed to render profiles: %w", err)
+		}
+		mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "profiles"}, &node)
+	}
+
+	data, err := yaml.Marshal(mapping)
+	if err != nil {
+		return "", fmt.Errorf("failed to render config: %w", err)
+	}
+	return strings.TrimRight(string(data), "\n"), nil
+}
+
+// runConfig runs the config command and returns its exit code
+func runConfig(args []string) int {
+	if len(args) == 0 || args[0] != "show" {
+		fmt.Fprint(os.Stdout, configUsage)
+		if len(args) > 0 && (args[0] == "-h" || args[0] == "--help") {
+			return ExitOK
+		}
+		return ExitUsage
+	}
+
+	cli := CLI{}
+	fs := flag.NewFlagSet("rescope config show", flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprint(os.Stdout, configUsage) }
+	cli.addFlags(fs)
+
+	if err := fs.Parse(rewriteOutputArgs(fs, args[1:])); err != nil {
+		return ExitUsage
+	}
+
+	sources, err := cli.loadConfig(fs)
+	if err != nil {
+		log.Error("Failed to load configuration", "error", err)
+		return ExitError
+	}
+
+	path, _ := cli.configPath()
+	output, err := getConfigOutput(fs, sources, cli.profiles)
+	if err != nil {
+		log.Error("Failed to write output", "error", err)
+		return ExitError
+	}
+
+	fmt.Fprintf(os.Stdout, "# config file: %s\n%s\n", path, output)
+	return ExitOK
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/root4loot/rescope/pkg/common"
+	"github.com/root4loot/rescope/pkg/scopefile"
+)
+
+//go:embed report.html
+var htmlReportTemplate string
+
+type htmlReport struct {
+	GeneratedAt string
+	Programs    []htmlProgram
+	Changes     *scopeChanges
+}
+
+type htmlProgram struct {
+	Title     string
+	PolicyURL string
+	FetchedAt string
+	InScope   []common.Asset
+	OutScope  []common.Asset
+}
+
+// scopeChanges lists the entries added and removed since an earlier snapshot
+type scopeChanges struct {
+	Since      string
+	AddedIn    []string
+	RemovedIn  []string
+	AddedOut   []string
+	RemovedOut []string
+}
+
+func (c *scopeChanges) Empty() bool {
+	return len(c.AddedIn)+len(c.RemovedIn)+len(c.AddedOut)+len(c.RemovedOut) == 0
+}
+
+// getHtmlOutput returns a self-contained HTML report with a tab per program.
+// If previous is set, it's read as the JSON output or scope file of an earlier
+// run and the report lists the changes since then. A report of a single
+// program, as written to --output-dir, is compared with that program's
+// entries only.
+func getHtmlOutput(result *common.Result, programs []*common.Result, previous string) (string, error) {
+	report := htmlReport{GeneratedAt: time.Now().Format(time.RFC3339)}
+
+	for _, program := range programs {
+		report.Programs = append(report.Programs, htmlProgram{
+			Title:     programTitle(program.ProgramDetails),
+			PolicyURL: program.ProgramDetails.PolicyURL,
+			FetchedAt: program.ProgramDetails.FetchedAt,
+			InScope:   scopeAssets(program, true),
+			OutScope:  scopeAssets(program, false),
+		})
+	}
+
+	if previous != "" {
+		snapshot, err := readSnapshot(previous)
+		if err != nil {
+			return "", err
+		}
+		var program *common.BugBountyProgram
+		if len(programs) == 1 && programs[0] == result {
+			program = &result.ProgramDetails
+		}
+		report.Changes = diffScope(previousScope(snapshot, program), result)
+	}
+
+	tmpl, err := template.New("report").Funcs(template.FuncMap{
+		"eligibility": eligibilityLabel,
+		"dict":        dict,
+	}).Parse(htmlReportTemplate)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse HTML template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, report); err != nil {
+		return "", fmt.Errorf("failed to render HTML report: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// readSnapshot reads the JSON output or scope file of an earlier run. A scope
+// file keeps the programs apart, while JSON output is read as one program.
+func readSnapshot(file string) ([]*common.Result, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read previous snapshot: %w", err)
+	}
+
+	if scopefile.Detect(data) {
+		parsed, err := scopefile.Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse previous snapshot: %w", err)
+		}
+		return scopefile.Results(parsed)
+	}
+
+	var snapshot common.Result
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse previous snapshot (expected JSON output or a scope file): %w", err)
+	}
+
+	return []*common.Result{&snapshot}, nil
+}
+
+// previousScope merges the programs of snapshot into one result, keeping only
+// those that are the same program as program if it is set
+func previousScope(snapshot []*common.Result, program *common.BugBountyProgram) *common.Result {
+	previous := &common.Result{}
+	matched := false
+
+	for _, result := range snapshot {
+		if program != nil && !sameProgram(result.ProgramDetails, *program) {
+			continue
+		}
+		if !matched {
+			previous.ProgramDetails = result.ProgramDetails
+			previous.FetchedAt = result.FetchedAt
+			matched = true
+		}
+		previous.InScope = append(previous.InScope, result.InScope...)
+		previous.OutScope = append(previous.OutScope, result.OutScope...)
+	}
+
+	return previous
+}
+
+// sameProgram reports whether a and b describe the same program, fetched
+// from the same URL or named the same on the same platform
+func sameProgram(a, b common.BugBountyProgram) bool {
+	if a.InputURL != "" && a.InputURL == b.InputURL {
+		return true
+	}
+	return strings.EqualFold(a.Platform, b.Platform) && a.Business == b.Business && a.ProgramName == b.ProgramName
+}
+
+// diffScope returns the entries added to and removed from the scope lists of
+// current compared with previous
+func diffScope(previous, current *common.Result) *scopeChanges {
+	since := previous.ProgramDetails.FetchedAt
+	if since == "" {
+		since = previous.FetchedAt
+	}
+
+	return &scopeChanges{
+		Since:      since,
+		AddedIn:    missingFrom(current.InScope, previous.InScope),
+		RemovedIn:  missingFrom(previous.InScope, current.InScope),
+		AddedOut:   missingFrom(current.OutScope, previous.OutScope),
+		RemovedOut: missingFrom(previous.OutScope, current.OutScope),
+	}
+}
+
+// missingFrom returns the items of a that are not in b
+func missingFrom(a, b []string) []string {
+	seen := make(map[string]bool, len(b))
+	for _, item := range b {
+		seen[item] = true
+	}
+
+	var missing []string
+	for _, item := range a {
+		if !seen[item] {
+			seen[item] = true
+			missing = append(missing, item)
+		}
+	}
+	return missing
+}
+
+// dict builds a map from key/value pairs, for passing several values to a
+// nested template
+func dict(pairs ...interface{}) (map[string]interface{}, error) {
+	if len(pairs)%2 != 0 {
+		return nil, fmt.Errorf("dict expects key/value pairs")
+	}
+
+	m := make(map[string]interface{}, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		key, ok := pairs[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("dict keys must be strings")
+		}
+		m[key] = pairs[i+1]
+	}
+	return m, nil
+}
@@ -3,24 +3,29 @@ package main
 import (
 	"bufio"
 	"encoding/json"
-	"encoding/xml"
+	"errors"
 	"flag"
 	"fmt"
 	"net/http"
 	"net/url"
 	"os"
-	"regexp"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/root4loot/goutils/fileutil"
 	"github.com/root4loot/goutils/iputil"
 	"github.com/root4loot/goutils/log"
 	"github.com/root4loot/goutils/sliceutil"
 	"github.com/root4loot/goutils/urlutil"
-	"github.com/root4loot/rescope/config"
+	"github.com/root4loot/rescope/pkg/bountytargets"
+	"github.com/root4loot/rescope/pkg/bugbounty/securitytxt"
 	"github.com/root4loot/rescope/pkg/common"
+	"github.com/root4loot/rescope/pkg/importer"
+	"github.com/root4loot/rescope/pkg/normalize"
+	"github.com/root4loot/rescope/pkg/redact"
 	"github.com/root4loot/rescope/pkg/rescope"
+	"github.com/root4loot/rescope/pkg/scopefile"
 	"github.com/root4loot/scope"
 )
 
@@ -29,101 +34,226 @@ const (
 	Version = "2.0.0"
 )
 
+// Exit codes
+const (
+	ExitOK           = 0
+	ExitError        = 1
+	ExitUsage        = 2 // invalid flags or arguments, as with the flag package
+	ExitNotFound     = 3
+	ExitAuthRequired = 4
+	ExitAuthInvalid  = 5
+	ExitRateLimited  = 6
+	ExitParseFailure = 7
+	ExitEmptyScope   = 8
+)
+
+// errUsage is returned (wrapped) by parseCLI for invalid arguments, as
+// opposed to a configuration that can't be loaded
+var errUsage = errors.New("invalid arguments")
+
 func init() {
 	log.Init(AppName)
+	redact.InstallHook()
 }
 
 type CLI struct {
-	Concurrency     int
-	Targets         []string
-	IncludeList     string
-	ExcludeList     string
-	TokenBugCrowd   string
-	TokenHackerOne  string
-	TokenIntigriti  string
-	TokenYesWeHack  string
-	OutputFile      string
-	OutputText      bool
-	OutputBurp      bool
-	OutputZap       bool
-	OutputJson      bool
-	OutputJsonLines bool
-	ExpandIPRanges  bool
-	Proxy           string
-	Debug           bool
+	Concurrency        int
+	Targets            []string
+	IncludeList        string
+	ExcludeList        string
+	CsvIdentifier      string
+	CsvType            string
+	CsvBounty          string
+	CsvInScope         string
+	CsvNotes           string
+	CsvSeverity        string
+	BountyTargets      string
+	BTPrograms         string
+	BTAll              bool
+	Offline            bool
+	AllPrograms        bool
+	AllProgramsPublic  bool
+	FollowedOnly       bool
+	SecurityTxtKey     string
+	TokenBugCrowd      string
+	TokenHackerOne     string
+	TokenIntigriti     string
+	TokenYesWeHack     string
+	OutputFile         string
+	OutputDir          string
+	OutputText         outputFlag
+	OutputBurp         outputFlag
+	OutputZap          outputFlag
+	OutputZapPlan      outputFlag
+	ZapContextName     string
+	ZapDescription     string
+	ZapTechInclude     string
+	ZapTechExclude     string
+	ZapPerProgram      bool
+	OutputCaido        outputFlag
+	OutputMitmproxy    outputFlag
+	OutputSquid        outputFlag
+	OutputPac          outputFlag
+	PacProxy           string
+	BurpLegacy         bool
+	BurpProject        string
+	OutputNmap         outputFlag
+	OutputNmapExcl     outputFlag
+	OutputMasscan      outputFlag
+	OutputHosts        outputFlag
+	OutputRoots        outputFlag
+	OutputTemplate     string
+	OutputTemplateFile string
+	OutputJson         outputFlag
+	OutputJsonLines    outputFlag
+	OutputYaml         outputFlag
+	OutputCsv          outputFlag
+	OutputMarkdown     outputFlag
+	OutputHtml         outputFlag
+	Previous           string
+	OutputScopeFile    outputFlag
+	ExpandIPRanges     bool
+	Minimize           bool
+	IncludeDerived     bool
+	DerivedMinConf     float64
+	BaseURLHackerOne   string
+	BaseURLBugcrowd    string
+	BaseURLIntigriti   string
+	BaseURLYesWeHack   string
+	UserHackerOne      string
+	UserBugcrowd       string
+	UserIntigriti      string
+	UserYesWeHack      string
+	Profile            string
+	StrictAuth         bool
+	Proxy              string
+	ConfigFile         string
+	TraceFile          string
+	Debug              bool
+
+	profiles map[string]map[string]string // credential profiles of the config file
+	sources  map[string]string            // where each option was set: flag, env or file
 }
 
 const usage = `
 Usage:
   rescope [options] [<BugBountyURL>...] [-iL <file>] [-eL <file>]
+  rescope programs [options]    list the programs visible to your accounts (see rescope programs -h)
+  rescope config show           print the effective configuration (see rescope config -h)
+  rescope auth check            verify the configured credentials (see rescope auth -h)
 
 INPUT:
-  -iL, --include-list         file containing list of URLs or custom in-scope definitions (newline separated)
-  -eL, --exclude-list         file containing list of URLs or custom out-of-scope definitions (newline separated)
+  -iL, --include-list         file containing list of URLs or custom in-scope definitions (newline separated, a scope file, a CSV export, or a Burp/ZAP scope file)
+  -eL, --exclude-list         file containing list of URLs or custom out-of-scope definitions (newline separated, a scope file, a CSV export, or a Burp/ZAP scope file)
+      --csv-identifier        CSV column holding the scope target (default: identifier, as in HackerOne exports)
+      --csv-type              CSV column holding the asset type (default: asset_type)
+      --csv-bounty            CSV column telling whether the asset is eligible for bounty (default: eligible_for_bounty)
+      --csv-in-scope          CSV column telling whether the asset is in scope (default: eligible_for_submission)
+      --csv-notes             CSV column holding notes (default: instruction)
+      --csv-severity          CSV column holding the maximum severity (default: max_severity)
+      --bounty-targets        bounty-targets-data dump(s) to read programs from (comma separated, e.g. hackerone_data.json)
+      --bounty-targets-program comma separated handles or URLs of programs to take from the dump
+      --bounty-targets-all    take every program in the dump
+      --offline               look up program URLs in the dump instead of fetching them
+      --all-programs          fetch the private programs your accounts are invited to on the platforms with an --auth-* token (requires -oD)
+      --all-programs-public   fetch every program your accounts can access, public ones included (requires -oD)
+      --followed-programs     fetch the programs your accounts follow (Intigriti) or bookmarked (HackerOne) (requires -oD)
+      --security-txt-key      trusted OpenPGP key file for signed security.txt files (else signatures are unverified)
 
 OUTPUT:
   -oF, --output-file          output to given file (default: stdout)
+  -oD, --output-dir           output one file per program and format to <dir>/<platform>/<program>/, plus index.json
 
 OUTPUT FORMAT:
+  (each format flag takes an optional file, e.g. -oB burp.json -oZ zap.context; several may be combined)
   -oT, --output-text          output simple text (default)
   -oB, --output-burp          output Burp Suite Scope (JSON)
+      --burp-legacy           output Burp scope in simple (URL prefix) mode instead of advanced mode
+      --burp-project          Burp project options file to merge the scope into (replaces only target.scope)
   -oZ, --output-zap           output ZAP Scope (XML)
+  -oZP, --output-zap-plan     output ZAP Automation Framework plan (YAML)
+      --zap-context-name      ZAP context name (default: program name)
+      --zap-description       ZAP context description
+      --zap-tech-include      comma separated technologies to include in the ZAP context (default: ZAP's common list)
+      --zap-tech-exclude      comma separated technologies to exclude from the ZAP context
+      --zap-per-program       one context per program in the ZAP plan
+  -oC, --output-caido         output Caido Scope Preset (JSON)
+  -oM, --output-mitmproxy     output mitmproxy options (YAML)
+  -oS, --output-squid         output Squid ACLs
+  -oP, --output-pac           output proxy auto-config (PAC) file
+      --pac-proxy             proxy used for in-scope hosts in PAC output (default: 127.0.0.1:8080)
+  -oN, --output-nmap          output nmap target list (-iL)
+  -oNX, --output-nmap-exclude output nmap exclude list (--excludefile)
+  -oMC, --output-masscan      output masscan configuration (ranges and excludes)
+  -oH, --output-hosts         output concrete in-scope hosts and URLs (e.g. for httpx, nuclei)
+  -oR, --output-roots         output root domains of in-scope wildcards (e.g. for subdomain enumeration)
+  -oTpl, --output-template    output rendered through the given Go text/template file
+      --output-template-file  file to write the rendered template to
   -oJ, --output-json          output JSON
   -oJL, --output-json-lines   output JSON lines
+  -oY, --output-yaml          output YAML (same structure as JSON)
+  -oCSV, --output-csv         output CSV report (one row per asset)
+  -oMD, --output-markdown     output Markdown report (one section per program)
+  -oHTML, --output-html       output self-contained HTML report
+      --previous              JSON output or scope file of an earlier run to list changes against in the HTML report
+  -oSF, --output-scope-file   output rescope scope file (YAML, or JSON if the file ends in .json), readable with -iL
+      --scope-file-schema     print the JSON Schema of the scope file format and exit
 
 OUTPUT FILTER:
   --filter-expand-ip-ranges   output individual IPs instead of IP ranges / CIDRs
+  --minimize                  remove entries covered by broader ones and merge overlapping CIDRs
+  --include-derived           add out-of-scope entries found in program descriptions to the out-of-scope list
+  --derived-min-confidence    minimum confidence (0-1) of derived entries to include (default: 0.5)
 
 AUTHORIZATION:
   --auth-bugcrowd             bugcrowd secret    (_bugcrowd_session=cookie.value) [Optional]
   --auth-hackerone            hackerone secret   (Authorization bearer token) [Optional]
   --auth-yeswehack            yeswehack secret   (Authorization bearer token) [Optional]
   --auth-intigriti            intigriti secret   (see https://app.intigriti.com/researcher/personal-access-tokens) [Optional]
+  --user-<platform>           account name of a platform's token (hackerone, bugcrowd, intigriti, yeswehack) [Optional]
+  --profile                   comma separated credential profiles of the config file to use, scopes fetched with each are merged
+  --strict-auth               verify tokens before use, and fail rather than fall back to public data if one is rejected
 
 GENERAL:
   -c, --concurrency           maximum number of concurrent requests (default: 5)
       --proxy                 proxy to use for requests (e.g. 127.0.0.1:8080)
-      --debug                 enable debug mode
+      --base-url-<platform>   send requests for a platform (hackerone, bugcrowd, intigriti, yeswehack) to this base URL instead
+      --config                config file (default: ~/.config/rescope/config.yaml)
+      --debug                 enable debug mode (tokens, cookies and CSRF values are redacted)
+      --trace                 write requests and responses to the given file, with credentials redacted
       --version               display version
+
+EXIT CODES:
+  0  success
+  1  general error
+  2  invalid flags or arguments
+  3  program not found
+  4  authentication required
+  5  invalid or expired token
+  6  rate limited (retry later)
+  7  unexpected platform response format
+  8  program returned an empty scope
 `
 
 func parseCLI() ([]string, *CLI, error) {
-	var version, help bool
+	var version, help, scopeSchema bool
 	cli := CLI{}
 
-	flag.StringVar(&cli.IncludeList, "iL", "", "")
-	flag.StringVar(&cli.IncludeList, "include-list", "", "")
-	flag.StringVar(&cli.ExcludeList, "eL", "", "")
-	flag.StringVar(&cli.ExcludeList, "exclude-list", "", "")
-	flag.StringVar(&cli.TokenHackerOne, "auth-hackerone", "", "")
-	flag.StringVar(&cli.TokenIntigriti, "auth-intigriti", "", "")
-	flag.StringVar(&cli.TokenYesWeHack, "auth-yeswehack", "", "")
-	flag.StringVar(&cli.TokenBugCrowd, "auth-bugcrowd", "", "")
-	flag.StringVar(&cli.OutputFile, "oF", "", "")
-	flag.StringVar(&cli.OutputFile, "output-file", "", "")
-	flag.BoolVar(&cli.OutputText, "oT", false, "")
-	flag.BoolVar(&cli.OutputText, "output-text", false, "")
-	flag.BoolVar(&cli.OutputBurp, "oB", false, "")
-	flag.BoolVar(&cli.OutputBurp, "output-burp", false, "")
-	flag.BoolVar(&cli.OutputZap, "oZ", false, "")
-	flag.BoolVar(&cli.OutputZap, "output-zap", false, "")
-	flag.BoolVar(&cli.OutputJson, "oJ", false, "")
-	flag.BoolVar(&cli.OutputJson, "output-json", false, "")
-	flag.BoolVar(&cli.OutputJsonLines, "oJL", false, "")
-	flag.BoolVar(&cli.OutputJsonLines, "output-json-lines", false, "")
-	flag.BoolVar(&cli.ExpandIPRanges, "expand-ip-ranges", false, "")
-	flag.IntVar(&cli.Concurrency, "concurrency", 5, "")
-	flag.StringVar(&cli.Proxy, "proxy", "", "")
-	flag.BoolVar(&cli.Debug, "debug", false, "")
+	cli.addFlags(flag.CommandLine)
+	flag.BoolVar(&scopeSchema, "scope-file-schema", false, "")
 	flag.BoolVar(&help, "h", false, "")
 	flag.BoolVar(&help, "help", false, "")
 	flag.BoolVar(&version, "version", false, "")
 
-	flag.Parse()
+	flag.CommandLine.Parse(rewriteOutputArgs(flag.CommandLine, os.Args[1:]))
 
-	if version {
-		log.Info(Version)
-		return nil, nil, fmt.Errorf("version: %s", Version)
+	if _, err := cli.loadConfig(flag.CommandLine); err != nil {
+		return nil, nil, err
+	}
+
+	if scopeSchema {
+		fmt.Fprint(os.Stdout, string(scopefile.Schema))
+		os.Exit(0)
 	}
 
 	var targets []string
@@ -132,7 +262,7 @@ func parseCLI() ([]string, *CLI, error) {
 		targets = args
 	}
 
-	if help || version || (len(flag.Args()) == 0 && cli.IncludeList == "" && cli.ExcludeList == "" && !hasStdin()) {
+	if help || version || (len(flag.Args()) == 0 && cli.IncludeList == "" && cli.ExcludeList == "" && cli.BountyTargets == "" && !cli.accountPrograms() && !hasStdin()) {
 		if help {
 			fmt.Fprint(os.Stdout, usage)
 			os.Exit(0)
@@ -140,65 +270,92 @@ func parseCLI() ([]string, *CLI, error) {
 			log.Infof("version: %s", Version)
 			os.Exit(0)
 		} else {
-			log.Error("Missing URL/file input. No targets provided")
 			fmt.Fprint(os.Stdout, usage)
-			os.Exit(1)
+			return nil, nil, fmt.Errorf("%w: missing URL/file input, no targets provided", errUsage)
 		}
 	}
 
+	if cli.accountPrograms() && cli.OutputDir == "" {
+		return nil, nil, fmt.Errorf("%w: --all-programs, --all-programs-public and --followed-programs write each program to its own directory, set one with -oD <dir>", errUsage)
+	}
+
 	return targets, &cli, nil
 }
 
+// accountPrograms reports whether the programs to fetch are listed on the
+// platforms the accounts have a token for
+func (cli *CLI) accountPrograms() bool {
+	return cli.AllPrograms || cli.AllProgramsPublic || cli.FollowedOnly
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "programs":
+			os.Exit(runPrograms(os.Args[2:]))
+		case "config":
+			os.Exit(runConfig(os.Args[2:]))
+		case "auth":
+			os.Exit(runAuth(os.Args[2:]))
+		}
+	}
+
 	args, cli, err := parseCLI()
 
-	if err != nil {
+	if errors.Is(err, errUsage) {
 		log.Error("Failed to parse CLI arguments", "error", err)
-		return
+		os.Exit(ExitUsage)
+	} else if err != nil {
+		log.Error("Failed to load configuration", "error", err)
+		os.Exit(ExitError)
 	}
 
-	opts := rescope.DefaultOptions()
-
-	if cli.Proxy != "" {
-		proxyURL, err := url.Parse("http://" + cli.Proxy)
-		if err != nil {
-			log.Errorf("Failed to parse proxy URL: %v\n", err)
-		}
-		transport := &http.Transport{
-			Proxy: http.ProxyURL(proxyURL),
-		}
-		opts.Client = &http.Client{
-			Transport: transport,
-		}
+	profiles, err := cli.profileOptions()
+	if err != nil {
+		log.Error("Failed to set up options", "error", err)
+		os.Exit(ExitError)
 	}
 
-	fileIncludes, fileExcludes, err := cli.getInputFileContents()
+	fileIncludes, fileExcludes, filePrograms, err := cli.getInputFileContents()
 	if err != nil {
 		log.Error("Failed to read input files", "error", err)
-		return
+		os.Exit(ExitError)
+	}
+
+	if cli.Offline && cli.BountyTargets == "" {
+		log.Error("--offline requires a dump given with --bounty-targets")
+		os.Exit(ExitError)
+	}
+	dump, err := cli.loadBountyTargets()
+	if err != nil {
+		log.Error("Failed to read bounty-targets dump", "error", err)
+		os.Exit(ExitError)
 	}
 
 	bugBountyURLs := []string{}
 	scope := scope.NewScope()
 
+	addToScope := func(item string, isInclude bool) {
+		normalized, unparsed := normalize.Normalize([]string{item})
+		for _, entry := range unparsed {
+			log.Warn("Could not parse scope entry, skipping", "entry", entry)
+		}
+
+		for _, entry := range normalized {
+			if isInclude {
+				scope.AddInclude(entry)
+			} else {
+				scope.AddExclude(entry)
+			}
+		}
+	}
+
 	processScopeList := func(list []string, isInclude bool) {
 		for _, item := range list {
-			if urlutil.IsURL(item) {
-				if rescope.IsBugBountyURL(item) {
-					bugBountyURLs = append(bugBountyURLs, item)
-				} else {
-					if isInclude {
-						scope.AddInclude(item)
-					} else {
-						scope.AddExclude(item)
-					}
-				}
+			if urlutil.IsURL(item) && rescope.IsBugBountyURL(item) {
+				bugBountyURLs = append(bugBountyURLs, item)
 			} else {
-				if isInclude {
-					scope.AddInclude(item)
-				} else {
-					scope.AddExclude(item)
-				}
+				addToScope(item, isInclude)
 			}
 		}
 	}
@@ -211,25 +368,58 @@ func main() {
 	}
 	processScopeList(args, true)
 
-	combinedResult := common.Result{}
-	firstResult := true
+	var listErr error
+	if cli.accountPrograms() {
+		var accountURLs []string
+		accountURLs, listErr = cli.accountProgramURLs(profiles)
+		if listErr != nil {
+			log.Error("Failed to list programs", "error", listErr)
+		}
+		bugBountyURLs = sliceutil.AppendUnique(bugBountyURLs, accountURLs...)
+	}
 
+	customResult := common.Result{}
 	if len(fileIncludes) > 0 || len(fileExcludes) > 0 {
-		combinedResult = processFileInputs(fileIncludes, fileExcludes, scope, cli)
+		customResult = processFileInputs(scope, cli)
 	}
 
-	cli.setAuthTokens(opts)
-	processURLs(bugBountyURLs, opts, cli, scope, &combinedResult, &firstResult)
-	printFormattedOutput(&combinedResult, cli)
-}
+	securityTxtKey, err := cli.securityTxtKey()
+	if err != nil {
+		log.Error("Failed to read security.txt key", "error", err)
+		os.Exit(ExitError)
+	}
+	for _, opts := range profiles {
+		opts.SecurityTxtKey = string(securityTxtKey)
+	}
+	programs := processFilePrograms(append(filePrograms, cli.bountyTargetsPrograms(dump)...), cli, scope)
+	fetched, errs := processURLs(bugBountyURLs, profiles, cli, scope, dump)
+	programs = append(programs, fetched...)
+	combinedResult := mergeResults(customResult, programs)
+
+	if cli.Minimize {
+		for _, result := range append(programs, &combinedResult) {
+			if _, err := cli.minimize(result); err != nil {
+				log.Error("Failed to minimize scope", "error", err)
+			}
+		}
+	}
 
-func processFileInputs(fileIncludes, fileExcludes []string, scope *scope.Scope, cli *CLI) common.Result {
-	initialResult := &common.Result{
-		InScope:  fileIncludes,
-		OutScope: fileExcludes,
+	if len(programs) == 0 {
+		// custom scope only, report it as a single unnamed program
+		programs = []*common.Result{&combinedResult}
+	}
+
+	cli.writeOutputs(&combinedResult, programs)
+
+	for _, err := range append(errs, listErr) {
+		if err != nil {
+			os.Exit(exitCode(err))
+		}
 	}
+}
 
-	scopedResult, err := getScopedResults(*initialResult, *scope)
+func processFileInputs(scope *scope.Scope, cli *CLI) common.Result {
+	scopedResult, err := getScopedResults(common.Result{}, *scope)
 	if err != nil {
 		log.Error("Failed to update results with scope", "error", err)
 		return common.Result{}
@@ -247,56 +437,233 @@ func processFileInputs(fileIncludes, fileExcludes []string, scope *scope.Scope,
 	}
 }
 
-func processURLs(urls []string, opts *rescope.Options, cli *CLI, scope *scope.Scope, combinedResult *common.Result, firstResult *bool) {
+// processURLs fetches each URL with the options of each profile and returns
+// the scoped results of the programs that could be fetched, followed by the
+// error (or nil) for each URL. Both are in input order.
+func processURLs(urls []string, profiles []*rescope.Options, cli *CLI, scope *scope.Scope, dump *bountytargets.Dump) ([]*common.Result, []error) {
 	sem := make(chan struct{}, cli.Concurrency)
+	results := make([]*common.Result, len(urls))
+	errs := make([]error, len(urls))
 	var wg sync.WaitGroup
 
-	for _, url := range urls {
+	for i, url := range urls {
 		sem <- struct{}{}
 		wg.Add(1)
 
-		go func(url string) {
+		go func(i int, url string) {
 			defer wg.Done()
 			defer func() { <-sem }()
 
 			log.Debug("Processing URL", "url", url)
 
-			_, err := rescope.IdentifyPlatform(url, opts)
+			_, err := rescope.IdentifyPlatform(url, profiles[0])
 			if err != nil {
 				log.Error("Unsupported or invalid bug bounty platform", "url", url)
+				errs[i] = err
 				return
 			}
 
-			bugBountyResult, err := rescope.Run(url, opts)
+			bugBountyResult, err := fetchProgram(url, profiles, dump, cli.Offline)
 			if err != nil {
-				log.Error("Failed to run rescope", "error", err)
+				log.Error(errorHint(err), "url", url, "error", err)
+				errs[i] = err
 				return
 			}
 
-			scopedResult, err := getScopedResults(*bugBountyResult, *scope)
-			if err != nil {
-				log.Error("Failed to update results with scope", "error", err)
-				return
+			for _, entry := range bugBountyResult.Unparsed {
+				log.Warn("Could not parse scope entry, skipping", "url", url, "entry", entry)
 			}
 
-			scopedResult, err = cli.applyOutputFilters(scopedResult)
-			if err != nil {
-				log.Error("Failed to apply filters", "error", err)
-				return
+			results[i] = cli.scopeProgram(bugBountyResult, scope)
+		}(i, url)
+	}
+
+	wg.Wait()
+
+	var programs []*common.Result
+	for _, result := range results {
+		if result != nil {
+			programs = append(programs, result)
+		}
+	}
+	return programs, errs
+}
+
+// fetchProgram fetches the program at url, merging the scopes fetched with
+// each profile, or looks it up in the dump when offline. If fetching fails,
+// the program is taken from the dump if it's there.
+func fetchProgram(url string, profiles []*rescope.Options, dump *bountytargets.Dump, offline bool) (*common.Result, error) {
+	if offline {
+		return dump.Find(url)
+	}
+
+	result, err := rescope.RunProfiles(url, profiles)
+	// strict mode fails rather than falling back to public data
+	if err != nil && len(dump.Programs) > 0 && !profiles[0].StrictAuth {
+		if cached, findErr := dump.Find(url); findErr == nil {
+			log.Warn("Failed to fetch program, using bounty-targets dump instead", "url", url, "error", err, "dump_date", cached.ProgramDetails.FetchedAt)
+			return cached, nil
+		}
+	}
+	return result, err
+}
+
+// loadBountyTargets reads the bounty-targets-data dumps given on the command
+// line. Programs are dated with the modification time of their dump.
+func (cli *CLI) loadBountyTargets() (*bountytargets.Dump, error) {
+	dump := &bountytargets.Dump{}
+
+	for _, file := range splitList(cli.BountyTargets) {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		loaded, err := bountytargets.Load(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+
+		var fetchedAt string
+		if info, err := os.Stat(file); err == nil {
+			fetchedAt = info.ModTime().Format(time.RFC3339)
+		}
+		for _, program := range loaded.Programs {
+			program.ProgramDetails.FetchedAt = fetchedAt
+		}
+
+		log.Debug("Read bounty-targets dump", "file", file, "programs", len(loaded.Programs))
+		dump.Programs = append(dump.Programs, loaded.Programs...)
+	}
+
+	return dump, nil
+}
+
+// bountyTargetsPrograms returns the programs selected from the dump with
+// --bounty-targets-program or --bounty-targets-all
+func (cli *CLI) bountyTargetsPrograms(dump *bountytargets.Dump) []*common.Result {
+	var programs []*common.Result
+
+	if cli.BTAll {
+		for _, program := range dump.Programs {
+			if len(program.InScope) > 0 || len(program.OutScope) > 0 {
+				programs = append(programs, program)
 			}
+		}
+		return programs
+	}
+
+	for _, query := range splitList(cli.BTPrograms) {
+		program, err := dump.Find(query)
+		if err != nil {
+			log.Error("Program not found", "program", query, "error", err)
+			continue
+		}
+		programs = append(programs, program)
+	}
+	return programs
+}
+
+// processFilePrograms returns the scoped results of the programs read from
+// scope files and dumps
+func processFilePrograms(programs []*common.Result, cli *CLI, scope *scope.Scope) []*common.Result {
+	var results []*common.Result
+	for _, program := range programs {
+		if result := cli.scopeProgram(program, scope); result != nil {
+			results = append(results, result)
+		}
+	}
+	return results
+}
 
-			if *firstResult {
-				combinedResult.ProgramDetails = scopedResult.ProgramDetails
-				*firstResult = false
+// scopeProgram adds the custom scope to a program result and applies the
+// output filters. It returns nil if that fails.
+func (cli *CLI) scopeProgram(result *common.Result, scope *scope.Scope) *common.Result {
+	if securityTxt := result.ProgramDetails.SecurityTxt; securityTxt != nil {
+		for _, policy := range securityTxt.Policy {
+			if rescope.IsBugBountyURL(policy) && !securitytxt.IsURL(policy) {
+				log.Info("security.txt policy is a bug bounty program, pass its URL to fetch the program scope", "url", policy)
 			}
+		}
+	}
+
+	scopedResult, err := getScopedResults(*result, *scope)
+	if err != nil {
+		log.Error("Failed to update results with scope", "error", err)
+		return nil
+	}
 
-			combinedResult.InScope = append(combinedResult.InScope, scopedResult.InScope...)
-			combinedResult.OutScope = append(combinedResult.OutScope, scopedResult.OutScope...)
+	scopedResult, err = cli.applyOutputFilters(scopedResult)
+	if err != nil {
+		log.Error("Failed to apply filters", "error", err)
+		return nil
+	}
+
+	return scopedResult
+}
+
+// mergeResults combines the custom scope and program results into one. The
+// program details are taken from the first program.
+func mergeResults(custom common.Result, programs []*common.Result) common.Result {
+	combined := custom
+	for i, result := range programs {
+		if i == 0 {
+			combined.ProgramDetails = result.ProgramDetails
+		}
+		combined.InScope = append(combined.InScope, result.InScope...)
+		combined.OutScope = append(combined.OutScope, result.OutScope...)
+		combined.Assets = append(combined.Assets, result.Assets...)
+		combined.Derived = append(combined.Derived, result.Derived...)
+	}
+	return combined
+}
 
-		}(url)
+// exitCode maps an error returned by rescope.Run to a process exit code
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, rescope.ErrProgramNotFound):
+		return ExitNotFound
+	case errors.Is(err, rescope.ErrAuthRequired):
+		return ExitAuthRequired
+	case errors.Is(err, rescope.ErrAuthInvalid):
+		return ExitAuthInvalid
+	case errors.Is(err, rescope.ErrRateLimited):
+		return ExitRateLimited
+	case errors.Is(err, rescope.ErrParse):
+		return ExitParseFailure
+	case errors.Is(err, rescope.ErrEmptyScope):
+		return ExitEmptyScope
+	default:
+		return ExitError
 	}
+}
 
-	wg.Wait()
+// errorHint returns an actionable message for an error returned by rescope.Run
+func errorHint(err error) string {
+	authFlag := "--auth-<platform>"
+	var platformErr *common.PlatformError
+	if errors.As(err, &platformErr) {
+		authFlag = "--auth-" + strings.ToLower(platformErr.Platform)
+	}
+
+	switch {
+	case errors.Is(err, rescope.ErrProgramNotFound):
+		return "Program not found. Check the URL, or set " + authFlag + " if the program is private"
+	case errors.Is(err, rescope.ErrAuthRequired):
+		return "Program requires authentication. Set " + authFlag
+	case errors.Is(err, rescope.ErrAuthExpired):
+		return "Token has expired. Refresh the token passed to " + authFlag
+	case errors.Is(err, rescope.ErrAuthInvalid):
+		return "Token was rejected. Refresh the token passed to " + authFlag
+	case errors.Is(err, rescope.ErrRateLimited):
+		return "Rate limited by platform. Retry later or lower --concurrency"
+	case errors.Is(err, rescope.ErrParse):
+		return "Unexpected response format from platform. Please report this issue"
+	case errors.Is(err, rescope.ErrEmptyScope):
+		return "Program returned an empty scope"
+	default:
+		return "Failed to run rescope"
+	}
 }
 
 func hasStdin() bool {
@@ -327,25 +694,6 @@ func processStdin() []string {
 	return targets
 }
 
-func printFormattedOutput(result *common.Result, cli *CLI) {
-	formattedOutput, err := cli.formatOutput(result)
-	if err != nil {
-		log.Error("Failed to format output", "error", err)
-		return
-	}
-
-	if cli.OutputFile != "" {
-		err := fileutil.WriteStringToFile(cli.OutputFile, formattedOutput)
-		if err != nil {
-			log.Error("Failed to save output to file", "error", err)
-			return
-		}
-		log.Info("Output saved to file", "file", cli.OutputFile)
-	} else {
-		fmt.Println(formattedOutput)
-	}
-}
-
 func getJsonOutput(result *common.Result) (string, error) {
 	jsonData, err := json.MarshalIndent(result, "", "  ")
 	if err != nil {
@@ -391,133 +739,6 @@ func getJsonLineOutput(result *common.Result) (string, error) {
 	return strings.Join(lines, "\n"), nil
 }
 
-func getBurpOutput(Result *common.Result) (string, error) {
-	var scope config.BurpConfig
-	scope.Target.Scope.AdvancedMode = true
-
-	for _, item := range Result.InScope {
-		protocol, host, port, file := parseAndReplaceWildcards(item)
-		includeEntry := config.BurpInclude{
-			Enabled:  true,
-			Protocol: protocol,
-			Host:     host,
-			Port:     port,
-			File:     file,
-		}
-
-		if protocol == "" {
-			includeEntry.Protocol = "any"
-		}
-
-		scope.Target.Scope.Include = append(scope.Target.Scope.Include, includeEntry)
-	}
-
-	for _, item := range Result.OutScope {
-		protocol, host, port, file := parseAndReplaceWildcards(item)
-		excludeEntry := config.BurpExclude{
-			Enabled:  true,
-			Protocol: protocol,
-			Host:     host,
-			Port:     port,
-			File:     file,
-		}
-
-		if protocol == "" {
-			excludeEntry.Protocol = "any"
-		}
-		scope.Target.Scope.Exclude = append(scope.Target.Scope.Exclude, excludeEntry)
-	}
-
-	output, err := json.MarshalIndent(scope, "", "  ")
-	if err != nil {
-		return "", fmt.Errorf("failed to serialize Burp scope to JSON: %w", err)
-	}
-
-	return string(output), nil
-}
-
-func getZapOutput(Result *common.Result) (string, error) {
-	var config config.ZapConfig
-	config.Context.Name = "MyContext"
-	config.Context.Inscope = "true"
-
-	config.Context.Forceduser = "-1"
-	config.Context.Authentication.Type = 0
-	config.Context.Authentication.Strategy = "EACH_RESP"
-	config.Context.Authentication.Pollurl = ""
-	config.Context.Authentication.Polldata = ""
-	config.Context.Authentication.Pollfreq = 60
-	config.Context.Authentication.Pollunits = "REQUESTS"
-	config.Context.Session.Type = 0
-	config.Context.Authorization.Type = 0
-	config.Context.Authorization.Basic.Header = ""
-	config.Context.Authorization.Basic.Body = ""
-	config.Context.Authorization.Basic.Logic = "AND"
-	config.Context.Authorization.Basic.Code = -1
-
-	appendWildcardIfNeeded := func(protocol, host, port, file string) string {
-		if file == "" || !strings.HasSuffix(file, ".*") {
-			file += ".*"
-		}
-
-		if protocol == "" {
-			protocol = "http(s)?"
-		}
-
-		if port != "" {
-			host = fmt.Sprintf("%s:%s", host, port)
-		}
-
-		return fmt.Sprintf("^%s://%s%s$", protocol, host, file)
-	}
-
-	processScope := func(scopeItems []string, appendTo *[]string) {
-		for _, item := range scopeItems {
-			if iputil.IsCIDR(item) || iputil.IsIPRange(item) || iputil.IsIP(item) {
-				ips, err := ipRangeToIPs([]string{item}) // Convert IP ranges/CIDRs to individual IPs
-				if err != nil {
-					continue
-				}
-				for _, ip := range ips {
-					protocol := "http(s)?"
-					host, file := ip, ""
-					regex := appendWildcardIfNeeded(protocol, host, "", file)
-					*appendTo = append(*appendTo, regex)
-				}
-			} else {
-				// For normal URLs
-				protocol, host, port, file := parseAndReplaceWildcards(item)
-				regex := appendWildcardIfNeeded(protocol, host, port, file)
-				*appendTo = append(*appendTo, regex)
-			}
-		}
-	}
-
-	processScope(Result.InScope, &config.Context.Incregexes)
-	processScope(Result.OutScope, &config.Context.Excregexes)
-
-	config.Context.Tech.Include = []string{
-		"Db", "Db.Firebird", "Db.HypersonicSQL", "Db.IBM DB2", "Db.Microsoft Access", "Db.Microsoft SQL Server",
-		"Db.MySQL", "Db.Oracle", "Db.PostgreSQL", "Db.SAP MaxDB", "Db.SQLite", "Db.Sybase",
-		"Language", "Language.ASP", "Language.C", "Language.PHP", "Language.XML",
-		"OS", "OS.Linux", "OS.MacOS", "OS.Windows",
-		"SCM", "SCM.Git", "SCM.SVN",
-		"WS", "WS.Apache", "WS.IIS", "WS.Tomcat",
-	}
-
-	config.Context.Urlparser.Class = "org.zaproxy.zap.model.StandardParameterParser"
-	config.Context.Urlparser.Config = "{\"kvps\":\"&\",\"kvs\":\"=\",\"struct\":[]}"
-	config.Context.Postparser.Class = "org.zaproxy.zap.model.StandardParameterParser"
-	config.Context.Postparser.Config = "{\"kvps\":\"&\",\"kvs\":\"=\",\"struct\":[]}"
-
-	output, err := xml.MarshalIndent(config, "", "  ")
-	if err != nil {
-		return "", fmt.Errorf("failed to serialize ZAP scope to XML: %w", err)
-	}
-
-	return xml.Header + string(output), nil
-}
-
 func getSimpleTextOutput(result *common.Result) string {
 	var builder strings.Builder
 
@@ -564,31 +785,33 @@ func ipRangeToIPs(scopeItems []string) ([]string, error) {
 	return converted, nil
 }
 
-func parseAndReplaceWildcards(input string) (protocol, host, port, file string) {
-	if iputil.IsIP(input) || iputil.IsCIDR(input) || iputil.IsIPRange(input) {
-		return "any", input, "", ""
-	}
-
-	r1 := regexp.MustCompile(`(?i)^(?:(?P<protocol>[a-z3]+):\/\/)?(?P<host>(?:(?:[a-z]+\.)?(?:\*\.)?\*?[a-zA-Z0-9-.*]+(?:\.[a-z]+|\.\*)))?(?::(?P<port>\d+))?(?P<file>[\/A-Za-z0-9-._~:/?#@!$&'*+=]*)?$`)
+// parseScopeEntries parses scope items for the output writers, splitting IP
+// ranges into CIDRs. Items that can't be parsed are skipped with a warning.
+func parseScopeEntries(items []string) []normalize.Entry {
+	var entries []normalize.Entry
+	for _, item := range items {
+		if cidrs, err := normalize.IPRangeToCIDRs(item); err == nil {
+			entries = append(entries, parseScopeEntries(cidrs)...)
+			continue
+		}
 
-	matches := r1.FindStringSubmatch(input)
-	if len(matches) == 0 {
-		return "", "", "", ""
+		entry, err := normalize.Parse(item)
+		if err != nil {
+			log.Warn("Skipping unsupported scope entry", "entry", item)
+			continue
+		}
+		entries = append(entries, entry)
 	}
-
-	protocol = matches[r1.SubexpIndex("protocol")]
-	host = matches[r1.SubexpIndex("host")]
-	host = strings.ReplaceAll(host, "*", ".*")
-	port = matches[r1.SubexpIndex("port")]
-	file = matches[r1.SubexpIndex("file")]
-	file = strings.ReplaceAll(file, "*", ".*")
-
-	return
+	return entries
 }
 
 func getScopedResults(result common.Result, scope scope.Scope) (*common.Result, error) {
 	var newResult common.Result
 	newResult.ProgramDetails = result.ProgramDetails
+	newResult.Assets = result.Assets
+	newResult.Derived = result.Derived
+	newResult.Unparsed = result.Unparsed
+	newResult.FetchedAt = result.FetchedAt
 
 	newResult.InScope = append(newResult.InScope, result.InScope...)
 	newResult.OutScope = append(newResult.OutScope, result.OutScope...)
@@ -608,44 +831,156 @@ func getScopedResults(result common.Result, scope scope.Scope) (*common.Result,
 	return &newResult, nil
 }
 
-func (cli *CLI) getInputFileContents() (includeTargets, excludeTargets []string, err error) {
+// getInputFileContents reads the include and exclude lists. Structured scope
+// files such as Burp or ZAP exports contribute their own includes and
+// excludes, whichever flag they were passed to, and scope files contribute
+// their programs.
+func (cli *CLI) getInputFileContents() (includeTargets, excludeTargets []string, programs []*common.Result, err error) {
 	if cli.IncludeList != "" {
-		includeTargets, err = fileutil.ReadFile(cli.IncludeList)
+		input, err := cli.readScopeFile(cli.IncludeList)
 		if err != nil {
-			return nil, nil, fmt.Errorf("failed to read bug bounty URLs file: %w", err)
+			return nil, nil, nil, fmt.Errorf("failed to read include file: %w", err)
 		}
+		includeTargets = append(includeTargets, input.Includes...)
+		excludeTargets = append(excludeTargets, input.Excludes...)
+		programs = append(programs, input.Programs...)
 	}
 
 	if cli.ExcludeList != "" {
-		excludeTargets, err = fileutil.ReadFile(cli.ExcludeList)
+		input, err := cli.readScopeFile(cli.ExcludeList)
 		if err != nil {
-			return nil, nil, fmt.Errorf("failed to read include file: %w", err)
+			return nil, nil, nil, fmt.Errorf("failed to read exclude file: %w", err)
+		}
+		if input.Structured {
+			includeTargets = append(includeTargets, input.Includes...)
+			excludeTargets = append(excludeTargets, input.Excludes...)
+			programs = append(programs, input.Programs...)
+		} else {
+			excludeTargets = append(excludeTargets, input.Includes...)
 		}
 	}
 
+	return includeTargets, excludeTargets, programs, nil
+}
+
+// securityTxtKey returns the key set with --security-txt-key, if any
+func (cli *CLI) securityTxtKey() ([]byte, error) {
+	if cli.SecurityTxtKey == "" {
+		return nil, nil
+	}
+
+	key, err := os.ReadFile(cli.SecurityTxtKey)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to read exclude file: %w", err)
+		return nil, fmt.Errorf("failed to read security.txt key: %w", err)
 	}
+	return key, nil
+}
 
-	return includeTargets, excludeTargets, nil
+// csvColumns returns the CSV column mapping set on the command line
+func (cli *CLI) csvColumns() importer.Columns {
+	return importer.Columns{
+		Identifier: cli.CsvIdentifier,
+		Type:       cli.CsvType,
+		Bounty:     cli.CsvBounty,
+		InScope:    cli.CsvInScope,
+		Notes:      cli.CsvNotes,
+		Severity:   cli.CsvSeverity,
+	}
 }
 
-func (cli *CLI) formatOutput(result *common.Result) (string, error) {
-	switch {
-	case cli.OutputJson:
-		return getJsonOutput(result)
-	case cli.OutputJsonLines:
-		return getJsonLineOutput(result)
-	case cli.OutputBurp:
-		return getBurpOutput(result)
-	case cli.OutputZap:
-		return getZapOutput(result)
-	default:
-		return getSimpleTextOutput(result), nil
+// scopeInput is the content of an include or exclude list
+type scopeInput struct {
+	Includes   []string
+	Excludes   []string
+	Programs   []*common.Result // programs defined in a scope or CSV file
+	Structured bool             // read from a scope, Burp or ZAP file rather than a plain list
+}
+
+// readScopeFile reads a scope file. Plain lists are returned line by line as
+// includes. CSV files are read with the column mapping set on the command
+// line, and are taken as CSV regardless of their header if an identifier
+// column is mapped.
+func (cli *CLI) readScopeFile(file string) (*scopeInput, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if scopefile.Detect(data) {
+		parsed, err := scopefile.Parse(data)
+		if err != nil {
+			return nil, err
+		}
+		programs, err := scopefile.Results(parsed)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, program := range programs {
+			for _, entry := range program.Unparsed {
+				log.Warn("Could not parse scope entry, skipping", "file", file, "entry", entry)
+			}
+		}
+		log.Debug("Read scope file", "file", file, "programs", len(programs))
+
+		return &scopeInput{Programs: programs, Structured: true}, nil
 	}
+
+	if securitytxt.Detect(data) {
+		key, err := cli.securityTxtKey()
+		if err != nil {
+			return nil, err
+		}
+		result, err := securitytxt.ReadFile(data, file, key)
+		if err != nil {
+			return nil, err
+		}
+		return &scopeInput{Programs: []*common.Result{result}, Structured: true}, nil
+	}
+
+	columns := cli.csvColumns()
+	format := importer.Detect(data)
+	if format == importer.FormatCSV || (format == "" && columns.Identifier != "") {
+		result, err := importer.CSV(data, columns)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range result.Unparsed {
+			log.Warn("Could not parse scope entry, skipping", "file", file, "entry", entry)
+		}
+		log.Debug("Imported CSV scope", "file", file, "includes", len(result.InScope), "excludes", len(result.OutScope))
+
+		return &scopeInput{Programs: []*common.Result{result}, Structured: true}, nil
+	}
+
+	if format == "" {
+		lines, err := fileutil.ReadFile(file)
+		return &scopeInput{Includes: lines}, err
+	}
+
+	scope, _, err := importer.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rule := range scope.Unparsed {
+		log.Warn("Could not convert scope rule, skipping", "file", file, "rule", rule)
+	}
+	log.Debug("Imported scope file", "file", file, "format", format, "includes", len(scope.Includes), "excludes", len(scope.Excludes))
+
+	return &scopeInput{Includes: scope.Includes, Excludes: scope.Excludes, Structured: true}, nil
 }
 
 func (cli *CLI) applyOutputFilters(Result *common.Result) (*common.Result, error) {
+	if cli.IncludeDerived {
+		for _, asset := range Result.Derived {
+			if asset.Confidence >= cli.DerivedMinConf {
+				Result.OutScope = sliceutil.AppendUnique(Result.OutScope, asset.Identifier)
+			}
+		}
+	}
+
 	if cli.ExpandIPRanges {
 		var err error
 		Result.InScope, err = ipRangeToIPs(Result.InScope)
@@ -662,11 +997,249 @@ func (cli *CLI) applyOutputFilters(Result *common.Result) (*common.Result, error
 	return Result, nil
 }
 
+// minimize removes redundant entries from the merged result. IP ranges merged
+// by minimization are expanded again if --expand-ip-ranges is set.
+func (cli *CLI) minimize(Result *common.Result) (*common.Result, error) {
+	Result.InScope, Result.OutScope = normalize.Minimize(Result.InScope, Result.OutScope)
+	return cli.applyOutputFilters(Result)
+}
+
+func (cli *CLI) zapOptions() zapOptions {
+	return zapOptions{
+		ContextName: cli.ZapContextName,
+		Description: cli.ZapDescription,
+		TechInclude: splitList(cli.ZapTechInclude),
+		TechExclude: splitList(cli.ZapTechExclude),
+		PerProgram:  cli.ZapPerProgram,
+	}
+}
+
+// splitList splits a comma separated flag value, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// addFlags adds the options of rescope to fs
+func (cli *CLI) addFlags(fs *flag.FlagSet) {
+	fs.StringVar(&cli.IncludeList, "iL", "", "")
+	fs.StringVar(&cli.IncludeList, "include-list", "", "")
+	fs.StringVar(&cli.ExcludeList, "eL", "", "")
+	fs.StringVar(&cli.ExcludeList, "exclude-list", "", "")
+	fs.StringVar(&cli.CsvIdentifier, "csv-identifier", "", "")
+	fs.StringVar(&cli.CsvType, "csv-type", "", "")
+	fs.StringVar(&cli.CsvBounty, "csv-bounty", "", "")
+	fs.StringVar(&cli.CsvInScope, "csv-in-scope", "", "")
+	fs.StringVar(&cli.CsvNotes, "csv-notes", "", "")
+	fs.StringVar(&cli.CsvSeverity, "csv-severity", "", "")
+	fs.StringVar(&cli.BountyTargets, "bounty-targets", "", "")
+	fs.StringVar(&cli.BTPrograms, "bounty-targets-program", "", "")
+	fs.BoolVar(&cli.BTAll, "bounty-targets-all", false, "")
+	fs.BoolVar(&cli.Offline, "offline", false, "")
+	fs.BoolVar(&cli.AllPrograms, "all-programs", false, "")
+	fs.BoolVar(&cli.AllProgramsPublic, "all-programs-public", false, "")
+	fs.BoolVar(&cli.FollowedOnly, "followed-programs", false, "")
+	fs.StringVar(&cli.SecurityTxtKey, "security-txt-key", "", "")
+	fs.StringVar(&cli.OutputFile, "oF", "", "")
+	fs.StringVar(&cli.OutputFile, "output-file", "", "")
+	fs.StringVar(&cli.OutputDir, "oD", "", "")
+	fs.StringVar(&cli.OutputDir, "output-dir", "", "")
+	fs.Var(&cli.OutputText, "oT", "")
+	fs.Var(&cli.OutputText, "output-text", "")
+	fs.Var(&cli.OutputBurp, "oB", "")
+	fs.Var(&cli.OutputBurp, "output-burp", "")
+	fs.BoolVar(&cli.BurpLegacy, "burp-legacy", false, "")
+	fs.StringVar(&cli.BurpProject, "burp-project", "", "")
+	fs.Var(&cli.OutputZap, "oZ", "")
+	fs.Var(&cli.OutputZap, "output-zap", "")
+	fs.Var(&cli.OutputZapPlan, "oZP", "")
+	fs.Var(&cli.OutputZapPlan, "output-zap-plan", "")
+	fs.StringVar(&cli.ZapContextName, "zap-context-name", "", "")
+	fs.StringVar(&cli.ZapDescription, "zap-description", "", "")
+	fs.StringVar(&cli.ZapTechInclude, "zap-tech-include", "", "")
+	fs.StringVar(&cli.ZapTechExclude, "zap-tech-exclude", "", "")
+	fs.BoolVar(&cli.ZapPerProgram, "zap-per-program", false, "")
+	fs.Var(&cli.OutputCaido, "oC", "")
+	fs.Var(&cli.OutputCaido, "output-caido", "")
+	fs.Var(&cli.OutputMitmproxy, "oM", "")
+	fs.Var(&cli.OutputMitmproxy, "output-mitmproxy", "")
+	fs.Var(&cli.OutputSquid, "oS", "")
+	fs.Var(&cli.OutputSquid, "output-squid", "")
+	fs.Var(&cli.OutputPac, "oP", "")
+	fs.Var(&cli.OutputPac, "output-pac", "")
+	fs.StringVar(&cli.PacProxy, "pac-proxy", "127.0.0.1:8080", "")
+	fs.Var(&cli.OutputNmap, "oN", "")
+	fs.Var(&cli.OutputNmap, "output-nmap", "")
+	fs.Var(&cli.OutputNmapExcl, "oNX", "")
+	fs.Var(&cli.OutputNmapExcl, "output-nmap-exclude", "")
+	fs.Var(&cli.OutputMasscan, "oMC", "")
+	fs.Var(&cli.OutputMasscan, "output-masscan", "")
+	fs.Var(&cli.OutputHosts, "oH", "")
+	fs.Var(&cli.OutputHosts, "output-hosts", "")
+	fs.Var(&cli.OutputRoots, "oR", "")
+	fs.Var(&cli.OutputRoots, "output-roots", "")
+	fs.StringVar(&cli.OutputTemplate, "oTpl", "", "")
+	fs.StringVar(&cli.OutputTemplate, "output-template", "", "")
+	fs.StringVar(&cli.OutputTemplateFile, "output-template-file", "", "")
+	fs.Var(&cli.OutputJson, "oJ", "")
+	fs.Var(&cli.OutputJson, "output-json", "")
+	fs.Var(&cli.OutputJsonLines, "oJL", "")
+	fs.Var(&cli.OutputJsonLines, "output-json-lines", "")
+	fs.Var(&cli.OutputYaml, "oY", "")
+	fs.Var(&cli.OutputYaml, "output-yaml", "")
+	fs.Var(&cli.OutputCsv, "oCSV", "")
+	fs.Var(&cli.OutputCsv, "output-csv", "")
+	fs.Var(&cli.OutputMarkdown, "oMD", "")
+	fs.Var(&cli.OutputMarkdown, "output-markdown", "")
+	fs.Var(&cli.OutputHtml, "oHTML", "")
+	fs.Var(&cli.OutputHtml, "output-html", "")
+	fs.StringVar(&cli.Previous, "previous", "", "")
+	fs.Var(&cli.OutputScopeFile, "oSF", "")
+	fs.Var(&cli.OutputScopeFile, "output-scope-file", "")
+	fs.BoolVar(&cli.ExpandIPRanges, "expand-ip-ranges", false, "")
+	fs.BoolVar(&cli.Minimize, "minimize", false, "")
+	fs.BoolVar(&cli.IncludeDerived, "include-derived", false, "")
+	fs.Float64Var(&cli.DerivedMinConf, "derived-min-confidence", 0.5, "")
+	fs.IntVar(&cli.Concurrency, "concurrency", 5, "")
+	cli.addSharedFlags(fs)
+}
+
+// addSharedFlags adds the flags shared by rescope and its commands
+func (cli *CLI) addSharedFlags(fs *flag.FlagSet) {
+	fs.StringVar(&cli.TokenHackerOne, "auth-hackerone", "", "")
+	fs.StringVar(&cli.TokenIntigriti, "auth-intigriti", "", "")
+	fs.StringVar(&cli.TokenYesWeHack, "auth-yeswehack", "", "")
+	fs.StringVar(&cli.TokenBugCrowd, "auth-bugcrowd", "", "")
+	fs.StringVar(&cli.UserHackerOne, "user-hackerone", "", "")
+	fs.StringVar(&cli.UserIntigriti, "user-intigriti", "", "")
+	fs.StringVar(&cli.UserYesWeHack, "user-yeswehack", "", "")
+	fs.StringVar(&cli.UserBugcrowd, "user-bugcrowd", "", "")
+	fs.StringVar(&cli.Profile, "profile", "", "")
+	fs.BoolVar(&cli.StrictAuth, "strict-auth", false, "")
+	fs.StringVar(&cli.BaseURLHackerOne, "base-url-hackerone", "", "")
+	fs.StringVar(&cli.BaseURLBugcrowd, "base-url-bugcrowd", "", "")
+	fs.StringVar(&cli.BaseURLIntigriti, "base-url-intigriti", "", "")
+	fs.StringVar(&cli.BaseURLYesWeHack, "base-url-yeswehack", "", "")
+	fs.StringVar(&cli.Proxy, "proxy", "", "")
+	fs.StringVar(&cli.ConfigFile, "config", "", "")
+	fs.StringVar(&cli.TraceFile, "trace", "", "")
+	fs.BoolVar(&cli.Debug, "debug", false, "")
+}
+
+// newOptions returns the library options set on the command line. The trace
+// file, if any, is created or truncated.
+func (cli *CLI) newOptions() (*rescope.Options, error) {
+	opts := rescope.DefaultOptions()
+
+	if cli.TraceFile != "" {
+		trace, err := os.OpenFile(cli.TraceFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+		if err != nil {
+			return nil, err
+		}
+		opts.Trace = trace
+	}
+
+	if cli.Proxy != "" {
+		proxyURL, err := url.Parse("http://" + cli.Proxy)
+		if err != nil {
+			log.Errorf("Failed to parse proxy URL: %v\n", err)
+		}
+		transport := &http.Transport{
+			Proxy: http.ProxyURL(proxyURL),
+		}
+		opts.Client = &http.Client{
+			Transport: transport,
+		}
+	}
+
+	opts.BaseURLs = map[string]string{}
+	for platform, baseURL := range map[string]string{
+		"hackerone": cli.BaseURLHackerOne,
+		"bugcrowd":  cli.BaseURLBugcrowd,
+		"intigriti": cli.BaseURLIntigriti,
+		"yeswehack": cli.BaseURLYesWeHack,
+	} {
+		if baseURL != "" {
+			opts.BaseURLs[platform] = baseURL
+		}
+	}
+
+	cli.setAuthTokens(opts)
+	opts.StrictAuth = cli.StrictAuth
+	return opts, nil
+}
+
+// profileOptions returns the library options of each profile selected with
+// --profile, or the options set on the command line if none is. Credentials
+// of a profile take precedence over those of the config file, but not over
+// credentials given as flags or environment variables.
+func (cli *CLI) profileOptions() ([]*rescope.Options, error) {
+	opts, err := cli.newOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	names := splitList(cli.Profile)
+	if len(names) == 0 {
+		return []*rescope.Options{opts}, nil
+	}
+
+	var profiles []*rescope.Options
+	for _, name := range names {
+		profile, ok := cli.profiles[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown profile: %s", name)
+		}
+
+		profileOpts := *opts
+		profileOpts.Profile = name
+		for option, value := range profile {
+			if source := cli.sources[option]; source == "flag" || source == "env" {
+				continue
+			}
+			setCredential(&profileOpts, option, value)
+		}
+		profiles = append(profiles, &profileOpts)
+	}
+	return profiles, nil
+}
+
+// setCredential sets a credential option of a profile
+func setCredential(opts *rescope.Options, option, value string) {
+	switch option {
+	case "auth-hackerone":
+		opts.AuthHackerOne = value
+	case "auth-intigriti":
+		opts.AuthIntigriti = value
+	case "auth-yeswehack":
+		opts.AuthYesWeHack = value
+	case "auth-bugcrowd":
+		opts.AuthBugcrowd = value
+	case "user-hackerone":
+		opts.UserHackerOne = value
+	case "user-intigriti":
+		opts.UserIntigriti = value
+	case "user-yeswehack":
+		opts.UserYesWeHack = value
+	case "user-bugcrowd":
+		opts.UserBugcrowd = value
+	}
+}
+
 func (cli *CLI) setAuthTokens(opts *rescope.Options) {
 	opts.AuthHackerOne = cli.TokenHackerOne
 	opts.AuthIntigriti = cli.TokenIntigriti
 	opts.AuthYesWeHack = cli.TokenYesWeHack
 	opts.AuthBugcrowd = cli.TokenBugCrowd
+	opts.UserHackerOne = cli.UserHackerOne
+	opts.UserIntigriti = cli.UserIntigriti
+	opts.UserYesWeHack = cli.UserYesWeHack
+	opts.UserBugcrowd = cli.UserBugcrowd
 
 	if cli.Debug {
 		opts.Debug = true
@@ -1,12 +1,20 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/pkg/errors"
+	"github.com/root4loot/rescope/pkg/common"
+	"github.com/root4loot/rescope/pkg/rescope"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestParseCLI_WithIncludeExclude(t *testing.T) {
@@ -33,19 +41,53 @@ func TestGetInputFileContents_ValidFiles(t *testing.T) {
 		ExcludeList: "exclude.txt",
 	}
 
-	includes, excludes, err := cli.getInputFileContents()
+	includes, excludes, _, err := cli.getInputFileContents()
 	assert.NoError(t, err, "Expected no error when reading valid files")
 	assert.Equal(t, strings.Split(includeContent, "\n"), includes, "Expected includes to match file content")
 	assert.Equal(t, strings.Split(excludeContent, "\n"), excludes, "Expected excludes to match file content")
 }
 
+func TestGetInputFileContents_StructuredFiles(t *testing.T) {
+	dir := t.TempDir()
+	scopeFile := filepath.Join(dir, "scope.yaml")
+	burpFile := filepath.Join(dir, "burp.json")
+
+	os.WriteFile(scopeFile, []byte("version: 1\nprograms:\n  - name: Acme\n    in_scope: [acme.com]"), 0644)
+	os.WriteFile(burpFile, []byte(`{"target":{"scope":{"advancedMode":true,
+		"include":[{"enabled":true,"protocol":"any","host":"^api\\.example\\.com$"}],
+		"exclude":[{"enabled":true,"protocol":"any","host":"^admin\\.example\\.com$"}]}}}`), 0644)
+
+	cli := CLI{
+		IncludeList: scopeFile,
+		ExcludeList: burpFile,
+	}
+
+	includes, excludes, programs, err := cli.getInputFileContents()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"api.example.com"}, includes, "Expected Burp includes to stay includes")
+	assert.Equal(t, []string{"admin.example.com"}, excludes)
+	assert.Len(t, programs, 1)
+	assert.Equal(t, "Acme", programs[0].ProgramDetails.ProgramName)
+	assert.Equal(t, []string{"acme.com"}, programs[0].InScope)
+
+	securityTxt := filepath.Join(dir, "security.txt")
+	os.WriteFile(securityTxt, []byte("Contact: mailto:security@acme.com\nExpires: 2030-01-01T00:00:00Z\n"), 0644)
+
+	cli = CLI{IncludeList: securityTxt}
+	_, _, programs, err = cli.getInputFileContents()
+	assert.NoError(t, err)
+	assert.Len(t, programs, 1)
+	assert.Equal(t, "security.txt", programs[0].ProgramDetails.Platform)
+	assert.Equal(t, []string{"acme.com"}, programs[0].InScope)
+}
+
 func TestGetInputFileContents_NonExistentFiles(t *testing.T) {
 	cli := CLI{
 		IncludeList: "nonexistent_include.txt",
 		ExcludeList: "nonexistent_exclude.txt",
 	}
 
-	_, _, err := cli.getInputFileContents()
+	_, _, _, err := cli.getInputFileContents()
 	assert.Error(t, err, "Expected error when reading non-existent files")
 }
 
@@ -86,3 +128,439 @@ func TestInvalidFlags(t *testing.T) {
 	err := fs.Parse([]string{"--invalid-flag"})
 	assert.Error(t, err, "Expected error with invalid flag")
 }
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected int
+	}{
+		{common.StatusError("HackerOne", 404, false), ExitNotFound},
+		{common.StatusError("Intigriti", 401, false), ExitAuthRequired},
+		{common.StatusError("Intigriti", 401, true), ExitAuthInvalid},
+		{common.NewPlatformError("Intigriti", common.ErrAuthExpired), ExitAuthInvalid},
+		{common.StatusError("Bugcrowd", 429, false), ExitRateLimited},
+		{common.ParseError("Bugcrowd", nil), ExitParseFailure},
+		{common.NewPlatformError("YesWeHack", common.ErrEmptyScope), ExitEmptyScope},
+		{common.StatusError("YesWeHack", 500, false), ExitError},
+	}
+
+	for _, test := range tests {
+		wrapped := errors.Wrap(test.err, "failed to run platform")
+		assert.Equal(t, test.expected, exitCode(wrapped), "Unexpected exit code for %v", test.err)
+	}
+}
+
+func TestGetCaidoOutput(t *testing.T) {
+	result := &common.Result{
+		ProgramDetails: common.BugBountyProgram{ProgramName: "example"},
+		InScope:        []string{"*.example.com", "https://api.example.com:8443/v1", "10.0.0.0/16", "10.1.0.0/23"},
+		OutScope:       []string{"staging.example.com", "https://example.com/admin"},
+	}
+
+	output, err := getCaidoOutput(result)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"name": "example",
+		"allowlist": ["*.example.com", "api.example.com", "10.0.*.*"],
+		"denylist": ["staging.example.com"]
+	}`, output)
+}
+
+func TestGetSquidOutput(t *testing.T) {
+	result := &common.Result{
+		InScope:  []string{"*.example.com", "10.0.0.0/24"},
+		OutScope: []string{"staging.example.com"},
+	}
+
+	output, err := getSquidOutput(result)
+	assert.NoError(t, err)
+	assert.Equal(t, `acl rescope_out_domains dstdomain staging.example.com
+acl rescope_in_wildcards dstdom_regex -i ^([^.]+\.)*[^.]+\.example\.com$
+acl rescope_in_networks dst 10.0.0.0/24
+
+http_access deny rescope_out_domains
+http_access allow rescope_in_wildcards
+http_access allow rescope_in_networks
+http_access deny all`, output)
+}
+
+func TestGetHostsAndRootsOutput(t *testing.T) {
+	result := &common.Result{
+		InScope:  []string{"*.example.com", "api.*.example.org", "www.example.net", "staging.example.net", "https://api.example.com/v1", "10.0.0.0/30"},
+		OutScope: []string{"staging.example.net"},
+	}
+
+	assert.Equal(t, "www.example.net\nhttps://api.example.com/v1", getHostsOutput(result))
+	assert.Equal(t, "example.com\nexample.org", getRootsOutput(result))
+}
+
+func TestGetTemplateOutput(t *testing.T) {
+	tmpl := `{{ .ProgramDetails.ProgramName }}{{ range .InScopeAssets }}
+{{ .Type }} {{ json .Identifier }} {{ wildcardRegex .Identifier }}{{ end }}`
+	tmplFile := filepath.Join(t.TempDir(), "scope.tmpl")
+	require.NoError(t, os.WriteFile(tmplFile, []byte(tmpl), 0644))
+
+	result := &common.Result{
+		ProgramDetails: common.BugBountyProgram{ProgramName: "example"},
+		InScope:        []string{"*.example.com", "10.0.0.0/24"},
+	}
+
+	output, err := getTemplateOutput(result, tmplFile)
+	assert.NoError(t, err)
+	assert.Equal(t, `example
+wildcard "*.example.com" (?:[^.]+\.)*[^.]+\.example\.com
+cidr "10.0.0.0/24" 10\.0\.0\.\d{1,3}`, output)
+}
+
+func TestGetReportOutputs(t *testing.T) {
+	eligible := true
+	result := &common.Result{
+		ProgramDetails: common.BugBountyProgram{ProgramName: "example", Platform: "Intigriti", PolicyURL: "https://example.com/policy"},
+		InScope:        []string{"*.example.com", "10.0.0.0/24"},
+		OutScope:       []string{"admin.example.com"},
+		Assets: []common.Asset{
+			{Identifier: "*.example.com", Type: "wildcard", Category: "Wildcard", InScope: true, BountyEligible: &eligible},
+		},
+	}
+
+	output, err := getCsvOutput([]*common.Result{result})
+	assert.NoError(t, err)
+	assert.Equal(t, `program,platform,scope,identifier,type,category,bounty_eligible
+example,Intigriti,in,*.example.com,wildcard,Wildcard,yes
+example,Intigriti,in,10.0.0.0/24,cidr,,
+example,Intigriti,out,admin.example.com,domain,,`, output)
+
+	output = getMarkdownOutput([]*common.Result{result})
+	assert.Contains(t, output, "## example (Intigriti)\n\n- Policy: <https://example.com/policy>\n")
+	assert.Contains(t, output, "| `*.example.com` | wildcard | Wildcard | yes |")
+	assert.Contains(t, output, "### Out of Scope\n\n| Identifier | Type | Category | Bounty |")
+
+	output, err = getYamlOutput(result)
+	assert.NoError(t, err)
+	assert.Contains(t, output, "in_scope:\n  - '*.example.com'\n  - 10.0.0.0/24\n")
+}
+
+func TestGetHtmlOutput(t *testing.T) {
+	previous := &common.Result{
+		ProgramDetails: common.BugBountyProgram{ProgramName: "example", Platform: "HackerOne", FetchedAt: "2024-01-01T00:00:00Z"},
+		InScope:        []string{"*.example.com", "old.example.org"},
+	}
+	data, err := json.Marshal(previous)
+	require.NoError(t, err)
+	previousFile := filepath.Join(t.TempDir(), "previous.json")
+	require.NoError(t, os.WriteFile(previousFile, data, 0644))
+
+	result := &common.Result{
+		ProgramDetails: common.BugBountyProgram{ProgramName: "example", Platform: "HackerOne"},
+		InScope:        []string{"*.example.com", "<new>.example.org"},
+	}
+
+	output, err := getHtmlOutput(result, []*common.Result{result}, previousFile)
+	assert.NoError(t, err)
+	assert.Contains(t, output, "the snapshot fetched 2024-01-01T00:00:00Z")
+	assert.Contains(t, output, `+ in scope: <code>&lt;new&gt;.example.org</code>`)
+	assert.Contains(t, output, `&minus; in scope: <code>old.example.org</code>`)
+	assert.Contains(t, output, `<span class="badge wildcard">wildcard</span>`)
+	assert.NotContains(t, output, "<script src=")
+}
+
+func TestGetHtmlOutputPerProgram(t *testing.T) {
+	previous := []*common.Result{
+		{ProgramDetails: common.BugBountyProgram{Platform: "HackerOne", ProgramName: "acme", FetchedAt: "2024-01-01T00:00:00Z"}, InScope: []string{"acme.com", "old.acme.com"}},
+		{ProgramDetails: common.BugBountyProgram{Platform: "Bugcrowd", ProgramName: "globex"}, InScope: []string{"globex.com"}},
+	}
+	snapshot, err := getScopeFileOutput(previous, false)
+	require.NoError(t, err)
+	previousFile := filepath.Join(t.TempDir(), "previous.yaml")
+	require.NoError(t, os.WriteFile(previousFile, []byte(snapshot), 0644))
+
+	acme := &common.Result{ProgramDetails: common.BugBountyProgram{Platform: "HackerOne", ProgramName: "acme"}, InScope: []string{"acme.com", "new.acme.com"}}
+	globex := &common.Result{ProgramDetails: common.BugBountyProgram{Platform: "Bugcrowd", ProgramName: "globex"}, InScope: []string{"globex.com"}}
+
+	output, err := getHtmlOutput(acme, []*common.Result{acme}, previousFile)
+	assert.NoError(t, err)
+	assert.Contains(t, output, `+ in scope: <code>new.acme.com</code>`)
+	assert.Contains(t, output, `&minus; in scope: <code>old.acme.com</code>`)
+	assert.NotContains(t, output, `&minus; in scope: <code>globex.com</code>`, "Expected other programs of the snapshot to be left out")
+
+	output, err = getHtmlOutput(globex, []*common.Result{globex}, previousFile)
+	assert.NoError(t, err)
+	assert.NotContains(t, output, "acme.com")
+
+	merged := mergeResults(common.Result{}, []*common.Result{acme, globex})
+	output, err = getHtmlOutput(&merged, []*common.Result{acme, globex}, previousFile)
+	assert.NoError(t, err)
+	assert.Contains(t, output, `&minus; in scope: <code>old.acme.com</code>`)
+	assert.NotContains(t, output, `&minus; in scope: <code>globex.com</code>`)
+}
+
+func TestOutputFlag(t *testing.T) {
+	var burp, json, text outputFlag
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(&burp, "oB", "")
+	fs.Var(&json, "oJ", "")
+	fs.Var(&text, "output-text", "")
+
+	assert.NoError(t, fs.Parse([]string{"-oB=burp.json", "-oJ=1", "--output-text", "https://hackerone.com/security"}))
+	assert.Equal(t, outputFlag{Enabled: true, File: "burp.json"}, burp)
+	assert.Equal(t, outputFlag{Enabled: true, File: "1"}, json, "Expected values other than true and false to name a file")
+	assert.Equal(t, outputFlag{Enabled: true}, text)
+	assert.Equal(t, []string{"https://hackerone.com/security"}, fs.Args())
+}
+
+func TestRewriteOutputArgs(t *testing.T) {
+	cli := CLI{}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	cli.addFlags(fs)
+
+	args := rewriteOutputArgs(fs, []string{"-iL", "in.txt", "-oB", "burp.json", "-oT", "out.txt", "-oZ=zap.context", "-oN", "-oH", "-oJ", "https://hackerone.com/security"})
+	assert.Equal(t, []string{"-iL", "in.txt", "-oB=burp.json", "-oT=out.txt", "-oZ=zap.context", "-oN", "-oH", "-oJ", "https://hackerone.com/security"}, args)
+
+	assert.NoError(t, fs.Parse(args))
+	assert.Equal(t, "burp.json", cli.OutputBurp.File)
+	assert.Equal(t, "out.txt", cli.OutputText.File)
+	assert.True(t, cli.OutputJson.Enabled)
+	assert.Equal(t, []string{"https://hackerone.com/security"}, fs.Args(), "Expected URLs after a format flag to be targets")
+
+	args = rewriteOutputArgs(fs, []string{"-oTpl", "scope.tmpl", "scope.txt", "--output-template=scope.tmpl", "out.txt", "-oTpl", "scope.tmpl", "https://hackerone.com/security"})
+	assert.Equal(t, []string{"-oTpl", "scope.tmpl", "--output-template-file=scope.txt", "--output-template=scope.tmpl", "--output-template-file=out.txt", "-oTpl", "scope.tmpl", "https://hackerone.com/security"}, args)
+
+	assert.Equal(t, []string{"-oB", "--", "out.txt"}, rewriteOutputArgs(fs, []string{"-oB", "--", "out.txt"}))
+}
+
+func TestWriteOutputDir(t *testing.T) {
+	dir := t.TempDir()
+	cli := &CLI{OutputDir: dir}
+	programs := []*common.Result{
+		{ProgramDetails: common.BugBountyProgram{Platform: "HackerOne", ProgramName: "security"}, InScope: []string{"*.hackerone.com"}},
+		{ProgramDetails: common.BugBountyProgram{Platform: "Bugcrowd", ProgramName: "../tesla"}, InScope: []string{"tesla.com"}},
+		{ProgramDetails: common.BugBountyProgram{Platform: "Intigriti", Business: "acme", ProgramName: "bugbounty"}, InScope: []string{"acme.com"}},
+		{ProgramDetails: common.BugBountyProgram{Platform: "Intigriti", Business: "globex", ProgramName: "bugbounty"}, InScope: []string{"globex.com"}},
+		{ProgramDetails: common.BugBountyProgram{ProgramName: "internal"}, InScope: []string{"a.example.com"}},
+		{ProgramDetails: common.BugBountyProgram{ProgramName: "internal"}, InScope: []string{"b.example.com"}},
+	}
+
+	cli.writeOutputs(programs[0], programs)
+
+	for _, file := range []string{
+		"hackerone/security/scope.txt",
+		"hackerone/security/scope.json",
+		"hackerone/security/scope.burp.json",
+		"bugcrowd/tesla/scope.txt",
+		"intigriti/acme-bugbounty/scope.txt",
+		"intigriti/globex-bugbounty/scope.txt",
+		"custom/internal/scope.txt",
+		"custom/internal-2/scope.txt",
+		"index.json",
+	} {
+		assert.FileExists(t, filepath.Join(dir, file))
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "index.json"))
+	assert.NoError(t, err)
+
+	var index []indexEntry
+	assert.NoError(t, json.Unmarshal(data, &index))
+	assert.Len(t, index, 6)
+	assert.Equal(t, "security", index[0].Program)
+	assert.Equal(t, 1, index[0].InScope)
+	assert.Contains(t, index[1].Files, "bugcrowd/tesla/scope.burp.json")
+}
+
+func TestGetBurpOutput(t *testing.T) {
+	result := &common.Result{
+		InScope:  []string{"*.example.com", "https://shop.example.com/cart"},
+		OutScope: []string{"admin.example.com"},
+	}
+
+	output, err := getBurpOutput(result, burpOptions{})
+	assert.NoError(t, err)
+	assert.Contains(t, output, `"host": "^(?:[^.]+\\.)*[^.]+\\.example\\.com$"`)
+	assert.Contains(t, output, `"host": "^admin\\.example\\.com$"`)
+	assert.Contains(t, output, `"file": "^/cart.*"`)
+
+	output, err = getBurpOutput(result, burpOptions{Legacy: true})
+	assert.NoError(t, err)
+	assert.Contains(t, output, `"advancedMode": false`)
+	assert.Contains(t, output, `"include_subdomains": true,
+          "prefix": "https://example.com"`)
+	assert.Contains(t, output, `"prefix": "https://shop.example.com/cart"`)
+
+	project := `{"proxy":{"intercept":false},"target":{"filter":{"show_only_in_scope":true},"scope":{"advancedMode":true,"include":[]}}}`
+	projectFile := filepath.Join(t.TempDir(), "project.json")
+	require.NoError(t, os.WriteFile(projectFile, []byte(project), 0644))
+
+	output, err = getBurpOutput(result, burpOptions{Project: projectFile})
+	assert.NoError(t, err)
+
+	var merged map[string]map[string]json.RawMessage
+	assert.NoError(t, json.Unmarshal([]byte(output), &merged))
+	assert.JSONEq(t, `false`, string(merged["proxy"]["intercept"]))
+	assert.JSONEq(t, `{"show_only_in_scope":true}`, string(merged["target"]["filter"]))
+	assert.Contains(t, string(merged["target"]["scope"]), `admin\\.example\\.com`)
+	assert.Less(t, strings.Index(output, `"proxy"`), strings.Index(output, `"target"`))
+}
+
+func TestGetZapOutput(t *testing.T) {
+	result := &common.Result{
+		ProgramDetails: common.BugBountyProgram{ProgramName: "example"},
+		InScope:        []string{"*.example.com", "10.0.0.0/16"},
+		OutScope:       []string{"https://admin.example.com/login"},
+	}
+	programs := []*common.Result{result}
+
+	output, err := getZapOutput(result, programs, zapOptions{TechExclude: []string{"Db.Oracle"}})
+	assert.NoError(t, err)
+	assert.Contains(t, output, "<name>example</name>")
+	assert.Contains(t, output, `<incregexes>^https?://(?:[^.]+\.)*[^.]+\.example\.com(?::\d+)?(?:[/?#].*)?$</incregexes>`)
+	assert.Contains(t, output, `<incregexes>^https?://10\.0\.\d{1,3}\.\d{1,3}(?::\d+)?(?:[/?#].*)?$</incregexes>`)
+	assert.Contains(t, output, `<excregexes>^https://admin\.example\.com(?::\d+)?/login.*$</excregexes>`)
+	assert.Contains(t, output, "<exclude>Db.Oracle</exclude>")
+	assert.NotContains(t, output, "<include>Db.Oracle</include>")
+
+	output, err = getZapPlanOutput(result, programs, zapOptions{ContextName: "custom"})
+	assert.NoError(t, err)
+	assert.Contains(t, output, "- name: custom\n")
+	assert.Contains(t, output, "- https://example.com\n")
+	assert.Contains(t, output, "context: custom\n")
+}
+
+func TestPrograms(t *testing.T) {
+	cli, err := parseProgramsCLI([]string{"-p", "intigriti,HackerOne", "--bounty-only", "--auth-intigriti", "token"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"intigriti", "HackerOne"}, cli.filter().Platforms)
+	assert.True(t, cli.filter().BountyOnly)
+	opts, err := cli.newOptions()
+	assert.NoError(t, err)
+	assert.Equal(t, "token", opts.AuthIntigriti)
+
+	_, err = parseProgramsCLI([]string{"--platform", "example"})
+	assert.Error(t, err, "Expected unsupported platforms to be rejected")
+
+	programs := []common.ProgramSummary{
+		{Platform: "Intigriti", Name: "Intigriti", URL: "https://app.intigriti.com/programs/intigriti/intigriti/detail", Type: "bug bounty", Status: "open", Bounty: true, MinBounty: 50, MaxBounty: 5000, Currency: "EUR", Private: true},
+		{Platform: "HackerOne", Name: "Acme", URL: "https://hackerone.com/acme", Type: "vdp", Status: "paused"},
+	}
+
+	assert.Equal(t, "https://app.intigriti.com/programs/intigriti/intigriti/detail\nhttps://hackerone.com/acme", getProgramsURLOutput(programs))
+
+	table := getProgramsTableOutput(programs)
+	assert.Contains(t, table, "50-5000 EUR")
+	assert.Contains(t, table, "private")
+	assert.Len(t, strings.Split(table, "\n"), 3)
+
+	assert.Equal(t, "up to 100 USD", formatBounty(common.ProgramSummary{Bounty: true, MaxBounty: 100, Currency: "USD"}))
+	assert.Equal(t, "-", formatBounty(programs[1]))
+
+	_, err = (&CLI{AllPrograms: true}).accountProgramURLs([]*rescope.Options{rescope.DefaultOptions()})
+	assert.True(t, errors.Is(err, common.ErrAuthRequired), "Expected a token to be required to list programs to fetch")
+}
+
+func TestAccountProgramURLs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"items": [
+			{"slug": "invited", "title": "Invited", "public": false, "bounty": true},
+			{"slug": "public", "title": "Public", "public": true, "bounty": true}
+		], "pagination": {"page": 1, "nb_pages": 1}}`))
+	}))
+	defer server.Close()
+
+	opts := rescope.DefaultOptions()
+	opts.AuthYesWeHack = "token"
+	opts.BaseURLs = map[string]string{"yeswehack": server.URL}
+
+	urls, err := (&CLI{AllPrograms: true}).accountProgramURLs([]*rescope.Options{opts})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"https://yeswehack.com/programs/invited"}, urls, "Expected only private programs by default")
+
+	urls, err = (&CLI{AllProgramsPublic: true}).accountProgramURLs([]*rescope.Options{opts})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"https://yeswehack.com/programs/invited", "https://yeswehack.com/programs/public"}, urls)
+}
+
+func TestConfig(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	os.WriteFile(configFile, []byte("auth-intigriti: file-token\nproxy: 127.0.0.1:8081\nconcurrency: 10\noutput-burp: true\nzap-tech-exclude: [Db, Language.PHP]\n"), 0644)
+
+	file, err := readConfigFile(configFile, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "Db,Language.PHP", file.Options["zap-tech-exclude"], "Expected lists to be joined as on the command line")
+
+	_, err = readConfigFile(filepath.Join(t.TempDir(), "missing.yaml"), false)
+	assert.NoError(t, err, "Expected a missing default config file to be ignored")
+
+	env := map[string]string{"RESCOPE_PROXY": "127.0.0.1:8082", "RESCOPE_AUTH_HACKERONE": "env-token"}
+	lookupEnv := func(name string) (string, bool) { value, ok := env[name]; return value, ok }
+
+	cli := CLI{}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	cli.addFlags(fs)
+	assert.NoError(t, fs.Parse([]string{"--auth-hackerone", "flag-token", "-oJ"}))
+
+	sources, err := applyConfig(fs, file.Options, lookupEnv)
+	assert.NoError(t, err)
+	assert.Equal(t, "flag-token", cli.TokenHackerOne, "Expected flags to take precedence over env")
+	assert.Equal(t, "127.0.0.1:8082", cli.Proxy, "Expected env to take precedence over the file")
+	assert.Equal(t, "file-token", cli.TokenIntigriti)
+	assert.Equal(t, 10, cli.Concurrency)
+	assert.False(t, cli.OutputBurp.Enabled, "Expected output formats on the command line to replace those of the file")
+	assert.Equal(t, map[string]string{"auth-hackerone": "flag", "output-json": "flag", "proxy": "env", "auth-intigriti": "file", "concurrency": "file", "zap-tech-exclude": "file"}, sources)
+
+	output, err := getConfigOutput(fs, sources, nil)
+	assert.NoError(t, err)
+	assert.NotContains(t, output, "token")
+	assert.Contains(t, output, "auth-intigriti: '[REDACTED]' # file")
+	assert.Contains(t, output, "proxy: 127.0.0.1:8082 # env RESCOPE_PROXY")
+	assert.Contains(t, output, "concurrency: 10 # file")
+}
+
+func TestProfiles(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	os.WriteFile(configFile, []byte("auth-hackerone: file-token\nprofiles:\n  alice:\n    auth-hackerone: alice-token\n    auth-intigriti: alice-intigriti\n    user-hackerone: alice\n  bob:\n    auth-hackerone: bob-token\n"), 0644)
+
+	cli := CLI{}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	cli.addFlags(fs)
+	assert.NoError(t, fs.Parse([]string{"--config", configFile, "--profile", "alice,bob", "--auth-intigriti", "flag-token"}))
+	_, err := cli.loadConfig(fs)
+	assert.NoError(t, err)
+
+	profiles, err := cli.profileOptions()
+	assert.NoError(t, err)
+	assert.Len(t, profiles, 2)
+	assert.Equal(t, "alice", profiles[0].Profile)
+	assert.Equal(t, "alice-token", profiles[0].AuthHackerOne, "Expected a profile to take precedence over the config file")
+	assert.Equal(t, "flag-token", profiles[0].AuthIntigriti, "Expected flags to take precedence over a profile")
+	assert.Equal(t, "alice", profiles[0].UserHackerOne)
+	assert.Equal(t, "bob-token", profiles[1].AuthHackerOne)
+	assert.Equal(t, "flag-token", profiles[1].AuthIntigriti)
+
+	output, err := getConfigOutput(fs, cli.sources, cli.profiles)
+	assert.NoError(t, err)
+	assert.NotContains(t, output, "alice-token")
+	assert.Contains(t, output, "user-hackerone: alice")
+
+	cli.Profile = "carol"
+	_, err = cli.profileOptions()
+	assert.ErrorContains(t, err, "unknown profile: carol")
+
+	os.WriteFile(configFile, []byte("profiles:\n  alice:\n    proxy: 127.0.0.1:8080\n"), 0644)
+	_, err = readConfigFile(configFile, true)
+	assert.Error(t, err, "Expected profiles to hold credentials only")
+}
+
+func TestAuthOutput(t *testing.T) {
+	statuses := []common.AuthStatus{
+		{Platform: "YesWeHack", Status: common.AuthValid, Account: "alice", ExpiresAt: "2026-11-01T00:00:00Z"},
+		{Platform: "Intigriti", Profile: "bob", Status: common.AuthExpired},
+	}
+
+	table := getAuthTableOutput(statuses)
+	assert.Len(t, strings.Split(table, "\n"), 3)
+	assert.Contains(t, table, "alice")
+	assert.Contains(t, table, "expired")
+
+	assert.Equal(t, ExitAuthInvalid, authExitCode(statuses))
+	assert.Equal(t, ExitError, authExitCode([]common.AuthStatus{{Status: common.AuthValid}, {Status: common.AuthError}}))
+	assert.Equal(t, ExitOK, authExitCode(statuses[:1]))
+}
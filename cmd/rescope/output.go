@@ -0,0 +1,360 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/root4loot/goutils/fileutil"
+	"github.com/root4loot/goutils/log"
+	"github.com/root4loot/rescope/pkg/common"
+)
+
+// outputFlag selects an output format. It works as a boolean flag (-oB) and
+// optionally takes the file the format is written to, as -oB=burp.json or
+// -oB burp.json (see rewriteOutputArgs). Only "true" and "false" are read as
+// booleans, so that any other value, such as -oB=1, names a file.
+type outputFlag struct {
+	Enabled bool
+	File    string
+}
+
+func (f *outputFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	if f.File != "" {
+		return f.File
+	}
+	return strconv.FormatBool(f.Enabled)
+}
+
+func (f *outputFlag) Set(value string) error {
+	switch value {
+	case "true":
+		f.Enabled = true
+	case "false":
+		f.Enabled = false
+	default:
+		f.Enabled = true
+		f.File = value
+	}
+	return nil
+}
+
+func (f *outputFlag) IsBoolFlag() bool {
+	return true
+}
+
+// rewriteOutputArgs joins output flags with a following file argument
+// (-oB burp.json becomes -oB=burp.json), as the flag package doesn't allow
+// separate values for boolean flags. The template format takes its template
+// as the value, so -oTpl scope.tmpl out.txt becomes
+// -oTpl scope.tmpl --output-template-file=out.txt.
+func rewriteOutputArgs(fs *flag.FlagSet, args []string) []string {
+	var rewritten []string
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return append(rewritten, args[i:]...)
+		}
+		rewritten = append(rewritten, arg)
+
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		f := fs.Lookup(name)
+		if f == nil || !strings.HasPrefix(arg, "-") {
+			continue
+		}
+
+		switch f.Value.(type) {
+		case *outputFlag:
+			if !hasValue && i+1 < len(args) && isOutputPath(args[i+1]) {
+				rewritten[len(rewritten)-1] = arg + "=" + args[i+1]
+				i++
+			}
+		default:
+			if name != "oTpl" && name != "output-template" {
+				continue
+			}
+			if !hasValue && i+1 < len(args) {
+				value = args[i+1]
+				rewritten = append(rewritten, value)
+				i++
+			}
+			if value != "" && i+1 < len(args) && isOutputPath(args[i+1]) {
+				rewritten = append(rewritten, "--output-template-file="+args[i+1])
+				i++
+			}
+		}
+	}
+
+	return rewritten
+}
+
+// isOutputPath reports whether arg following an output flag is the file to
+// write to. Targets are program URLs, so anything but a URL or a flag is
+// taken as the file.
+func isOutputPath(arg string) bool {
+	return arg != "" && !strings.HasPrefix(arg, "-") && !strings.Contains(arg, "://")
+}
+
+// outputFormat is an output format that can be selected on the command line
+type outputFormat struct {
+	name   string
+	file   string // file name in program directories
+	flag   *outputFlag
+	render func(result *common.Result, programs []*common.Result) (string, error)
+}
+
+// outputFormats returns all output formats in order of precedence for the
+// default destination. Report formats render each program separately.
+func (cli *CLI) outputFormats() []outputFormat {
+	plain := func(render func(*common.Result) string) func(*common.Result, []*common.Result) (string, error) {
+		return func(result *common.Result, _ []*common.Result) (string, error) {
+			return render(result), nil
+		}
+	}
+	merged := func(render func(*common.Result) (string, error)) func(*common.Result, []*common.Result) (string, error) {
+		return func(result *common.Result, _ []*common.Result) (string, error) {
+			return render(result)
+		}
+	}
+
+	return []outputFormat{
+		{"template", "scope.template.txt", &outputFlag{Enabled: cli.OutputTemplate != "", File: cli.OutputTemplateFile}, merged(func(result *common.Result) (string, error) {
+			return getTemplateOutput(result, cli.OutputTemplate)
+		})},
+		{"json", "scope.json", &cli.OutputJson, merged(getJsonOutput)},
+		{"json-lines", "scope.jsonl", &cli.OutputJsonLines, merged(getJsonLineOutput)},
+		{"yaml", "scope.yaml", &cli.OutputYaml, merged(getYamlOutput)},
+		{"scope-file", "scope.rescope.yaml", &cli.OutputScopeFile, func(_ *common.Result, programs []*common.Result) (string, error) {
+			return getScopeFileOutput(programs, strings.EqualFold(filepath.Ext(cli.OutputScopeFile.File), ".json"))
+		}},
+		{"csv", "scope.csv", &cli.OutputCsv, func(_ *common.Result, programs []*common.Result) (string, error) {
+			return getCsvOutput(programs)
+		}},
+		{"markdown", "scope.md", &cli.OutputMarkdown, func(_ *common.Result, programs []*common.Result) (string, error) {
+			return getMarkdownOutput(programs), nil
+		}},
+		{"html", "scope.html", &cli.OutputHtml, func(result *common.Result, programs []*common.Result) (string, error) {
+			return getHtmlOutput(result, programs, cli.Previous)
+		}},
+		{"burp", "scope.burp.json", &cli.OutputBurp, merged(func(result *common.Result) (string, error) {
+			return getBurpOutput(result, burpOptions{Legacy: cli.BurpLegacy, Project: cli.BurpProject})
+		})},
+		{"zap", "scope.context", &cli.OutputZap, func(result *common.Result, programs []*common.Result) (string, error) {
+			return getZapOutput(result, programs, cli.zapOptions())
+		}},
+		{"zap-plan", "scope.zap.yaml", &cli.OutputZapPlan, func(result *common.Result, programs []*common.Result) (string, error) {
+			return getZapPlanOutput(result, programs, cli.zapOptions())
+		}},
+		{"caido", "scope.caido.json", &cli.OutputCaido, merged(getCaidoOutput)},
+		{"mitmproxy", "scope.mitmproxy.yaml", &cli.OutputMitmproxy, merged(getMitmproxyOutput)},
+		{"squid", "scope.squid.conf", &cli.OutputSquid, merged(getSquidOutput)},
+		{"pac", "scope.pac", &cli.OutputPac, merged(func(result *common.Result) (string, error) {
+			return getPacOutput(result, cli.PacProxy)
+		})},
+		{"nmap", "scope.nmap.txt", &cli.OutputNmap, plain(getNmapOutput)},
+		{"nmap-exclude", "scope.nmap-exclude.txt", &cli.OutputNmapExcl, plain(getNmapExcludeOutput)},
+		{"masscan", "scope.masscan.conf", &cli.OutputMasscan, plain(getMasscanOutput)},
+		{"hosts", "scope.hosts.txt", &cli.OutputHosts, plain(getHostsOutput)},
+		{"roots", "scope.roots.txt", &cli.OutputRoots, plain(getRootsOutput)},
+		{"text", "scope.txt", &cli.OutputText, plain(getSimpleTextOutput)},
+	}
+}
+
+// writeOutputs renders every selected format from the same results. Formats
+// given their own file are written there. The first of the remaining formats
+// goes to --output-file or stdout, falling back to text unless every selected
+// format has its own file. With --output-dir, the remaining formats are
+// written per program instead.
+func (cli *CLI) writeOutputs(result *common.Result, programs []*common.Result) {
+	formats := cli.outputFormats()
+	var standard []outputFormat
+
+	for _, format := range formats {
+		if !format.flag.Enabled {
+			continue
+		}
+
+		if format.flag.File == "" {
+			standard = append(standard, format)
+			continue
+		}
+
+		writeOutput(format, result, programs, format.flag.File)
+	}
+
+	if cli.OutputDir != "" {
+		if len(standard) == 0 && !cli.hasOutputFiles() {
+			standard = defaultDirFormats(formats)
+		}
+		if err := writeOutputDir(cli.OutputDir, standard, programs); err != nil {
+			log.Error("Failed to write output directory", "dir", cli.OutputDir, "error", err)
+		}
+		return
+	}
+
+	if len(standard) == 0 {
+		if cli.OutputFile == "" && cli.hasOutputFiles() {
+			return
+		}
+		standard = formats[len(formats)-1:] // text
+	}
+
+	for _, format := range standard[1:] {
+		log.Warn("Several output formats without a file given, only the first is written", "ignored", format.name)
+	}
+
+	writeOutput(standard[0], result, programs, cli.OutputFile)
+}
+
+// hasOutputFiles reports whether any output format was given its own file
+func (cli *CLI) hasOutputFiles() bool {
+	for _, format := range cli.outputFormats() {
+		if format.flag.Enabled && format.flag.File != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// writeOutput renders format and writes it to file, or stdout if file is empty
+func writeOutput(format outputFormat, result *common.Result, programs []*common.Result, file string) {
+	formattedOutput, err := format.render(result, programs)
+	if err != nil {
+		log.Error("Failed to format output", "error", err)
+		return
+	}
+
+	if file == "" {
+		fmt.Println(formattedOutput)
+		return
+	}
+
+	if err := fileutil.WriteStringToFile(file, formattedOutput); err != nil {
+		log.Error("Failed to save output to file", "file", file, "error", err)
+		return
+	}
+	log.Info("Output saved to file", "file", file)
+}
+
+// defaultDirFormats returns the formats written per program when --output-dir
+// is used without selecting any
+func defaultDirFormats(formats []outputFormat) []outputFormat {
+	var defaults []outputFormat
+	for _, format := range formats {
+		switch format.name {
+		case "text", "json", "burp":
+			defaults = append(defaults, format)
+		}
+	}
+	return defaults
+}
+
+// indexEntry summarizes a program in the output directory index
+type indexEntry struct {
+	Platform  string   `json:"platform"`
+	Program   string   `json:"program"`
+	InputURL  string   `json:"input_url"`
+	PolicyURL string   `json:"policy_url"`
+	FetchedAt string   `json:"fetched_at"`
+	InScope   int      `json:"in_scope"`
+	OutScope  int      `json:"out_scope"`
+	Files     []string `json:"files"` // relative to the output directory
+}
+
+// writeOutputDir writes each format for each program to
+// <dir>/<platform>/<program>/ and an index.json summarizing all programs.
+// Programs whose handle is only unique within a business, as on Intigriti,
+// are written to <dir>/<platform>/<business>-<program>/.
+func writeOutputDir(dir string, formats []outputFormat, programs []*common.Result) error {
+	index := []indexEntry{}
+	used := make(map[string]bool)
+
+	for _, program := range programs {
+		details := program.ProgramDetails
+		programDir := filepath.Join(pathSegment(details.Platform, "custom"), programDirName(details))
+
+		// programs that still share a directory, such as custom programs of
+		// the same name, get a numbered one rather than overwriting each other
+		if used[programDir] {
+			base := programDir
+			for n := 2; used[programDir]; n++ {
+				programDir = fmt.Sprintf("%s-%d", base, n)
+			}
+			log.Warn("Programs share a directory name, numbering it", "program", details.ProgramName, "dir", filepath.ToSlash(programDir))
+		}
+		used[programDir] = true
+
+		if err := os.MkdirAll(filepath.Join(dir, programDir), 0755); err != nil {
+			return err
+		}
+
+		entry := indexEntry{
+			Platform:  details.Platform,
+			Program:   details.ProgramName,
+			InputURL:  details.InputURL,
+			PolicyURL: details.PolicyURL,
+			FetchedAt: details.FetchedAt,
+			InScope:   len(program.InScope),
+			OutScope:  len(program.OutScope),
+			Files:     []string{},
+		}
+
+		for _, format := range formats {
+			file := filepath.Join(programDir, format.file)
+			formattedOutput, err := format.render(program, []*common.Result{program})
+			if err != nil {
+				log.Error("Failed to format output", "program", details.ProgramName, "format", format.name, "error", err)
+				continue
+			}
+			if err := fileutil.WriteStringToFile(filepath.Join(dir, file), formattedOutput); err != nil {
+				return err
+			}
+			entry.Files = append(entry.Files, filepath.ToSlash(file))
+		}
+
+		index = append(index, entry)
+	}
+
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize index to JSON: %w", err)
+	}
+
+	if err := fileutil.WriteStringToFile(filepath.Join(dir, "index.json"), string(data)); err != nil {
+		return err
+	}
+
+	log.Info("Output saved to directory", "dir", dir, "programs", len(programs))
+	return nil
+}
+
+// programDirName returns the directory name of a program within its
+// platform's directory
+func programDirName(details common.BugBountyProgram) string {
+	name := pathSegment(details.ProgramName, "custom")
+	if details.Business != "" && !strings.EqualFold(details.Business, details.ProgramName) {
+		name = pathSegment(details.Business, "custom") + "-" + name
+	}
+	return name
+}
+
+// pathSegmentRegex matches runs of characters not allowed in directory names
+var pathSegmentRegex = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// pathSegment turns name into a safe lowercase directory name
+func pathSegment(name, fallback string) string {
+	segment := strings.Trim(pathSegmentRegex.ReplaceAllString(strings.ToLower(name), "-"), "-.")
+	if segment == "" {
+		return fallback
+	}
+	return segment
+}
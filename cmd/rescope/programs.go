@@ -0,0 +1,334 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/root4loot/goutils/log"
+	"github.com/root4loot/goutils/sliceutil"
+	"github.com/root4loot/rescope/pkg/common"
+	"github.com/root4loot/rescope/pkg/rescope"
+)
+
+const programsUsage = `
+Usage:
+  rescope programs [options]
+
+Lists the programs visible to your accounts on each platform, public programs
+and private invitations alike. Platforms without a token list their public
+programs only. Piped output is one program URL per line, which rescope reads
+from stdin:
+
+  rescope programs --bounty-only --platform intigriti --auth-intigriti <token> | rescope -oB
+
+FILTER:
+  -p, --platform              comma separated platforms to list (hackerone, bugcrowd, intigriti, yeswehack)
+      --bounty-only           only programs that pay bounties
+      --private-only          only private programs
+      --followed-only         only programs followed (Intigriti) or bookmarked (HackerOne)
+
+OUTPUT:
+  -u, --urls                  output program URLs only (default when piped)
+  -oJ, --output-json          output JSON
+
+AUTHORIZATION:
+  --auth-bugcrowd             bugcrowd secret    (_bugcrowd_session=cookie.value) [Optional]
+  --auth-hackerone            hackerone secret   (Authorization bearer token) [Optional]
+  --auth-yeswehack            yeswehack secret   (Authorization bearer token) [Optional]
+  --auth-intigriti            intigriti secret   (see https://app.intigriti.com/researcher/personal-access-tokens) [Optional]
+  --user-<platform>           account name of a platform's token (hackerone, bugcrowd, intigriti, yeswehack) [Optional]
+  --profile                   comma separated credential profiles of the config file to use, scopes fetched with each are merged
+  --strict-auth               verify tokens before use, and fail rather than fall back to public data if one is rejected
+
+GENERAL:
+      --proxy                 proxy to use for requests (e.g. 127.0.0.1:8080)
+      --base-url-<platform>   send requests for a platform (hackerone, bugcrowd, intigriti, yeswehack) to this base URL instead
+      --config                config file (default: ~/.config/rescope/config.yaml)
+      --debug                 enable debug mode (tokens, cookies and CSRF values are redacted)
+      --trace                 write requests and responses to the given file, with credentials redacted
+`
+
+// programsCLI holds the options of the programs command
+type programsCLI struct {
+	CLI
+	Platforms    string
+	BountyOnly   bool
+	PrivateOnly  bool
+	FollowedOnly bool
+	URLsOnly     bool
+	OutputJson   bool
+}
+
+func parseProgramsCLI(args []string) (*programsCLI, error) {
+	var help bool
+	cli := programsCLI{}
+
+	fs := flag.NewFlagSet("rescope programs", flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprint(os.Stdout, programsUsage) }
+	cli.addProgramsFlags(fs)
+	cli.addSharedFlags(fs)
+	fs.BoolVar(&help, "h", false, "")
+	fs.BoolVar(&help, "help", false, "")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if _, err := cli.loadConfig(fs); err != nil {
+		return nil, err
+	}
+
+	if help {
+		fmt.Fprint(os.Stdout, programsUsage)
+		os.Exit(0)
+	}
+
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+
+	for _, platform := range splitList(cli.Platforms) {
+		switch strings.ToLower(platform) {
+		case "hackerone", "bugcrowd", "intigriti", "yeswehack":
+		default:
+			return nil, fmt.Errorf("unsupported platform: %s", platform)
+		}
+	}
+
+	return &cli, nil
+}
+
+// addProgramsFlags adds the options of the programs command, other than
+// those shared with rescope, to fs
+func (cli *programsCLI) addProgramsFlags(fs *flag.FlagSet) {
+	fs.StringVar(&cli.Platforms, "p", "", "")
+	fs.StringVar(&cli.Platforms, "platform", "", "")
+	fs.BoolVar(&cli.BountyOnly, "bounty-only", false, "")
+	fs.BoolVar(&cli.PrivateOnly, "private-only", false, "")
+	fs.BoolVar(&cli.FollowedOnly, "followed-only", false, "")
+	fs.BoolVar(&cli.URLsOnly, "u", false, "")
+	fs.BoolVar(&cli.URLsOnly, "urls", false, "")
+	fs.BoolVar(&cli.OutputJson, "oJ", false, "")
+	fs.BoolVar(&cli.OutputJson, "output-json", false, "")
+}
+
+// filter returns the program filter set on the command line
+func (cli *programsCLI) filter() rescope.ProgramFilter {
+	return rescope.ProgramFilter{
+		Platforms:    splitList(cli.Platforms),
+		BountyOnly:   cli.BountyOnly,
+		PrivateOnly:  cli.PrivateOnly,
+		FollowedOnly: cli.FollowedOnly,
+	}
+}
+
+// runPrograms runs the programs command and returns its exit code. Programs
+// of the platforms that could be listed are printed even if others failed.
+func runPrograms(args []string) int {
+	cli, err := parseProgramsCLI(args)
+	if err != nil {
+		log.Error("Failed to parse CLI arguments", "error", err)
+		return ExitUsage
+	}
+
+	profiles, err := cli.profileOptions()
+	if err != nil {
+		log.Error("Failed to set up options", "error", err)
+		return ExitError
+	}
+
+	programs, listErr := listPrograms(cli.filter(), profiles)
+
+	var output string
+	switch {
+	case cli.OutputJson:
+		output, err = getProgramsJsonOutput(programs)
+		if err != nil {
+			log.Error("Failed to write output", "error", err)
+			return ExitError
+		}
+	case cli.URLsOnly || !isTerminal(os.Stdout):
+		output = getProgramsURLOutput(programs)
+	default:
+		output = getProgramsTableOutput(programs)
+	}
+
+	if output != "" {
+		fmt.Fprintln(os.Stdout, output)
+	}
+
+	if listErr != nil {
+		hint := errorHint(listErr)
+		if exitCode(listErr) == ExitError {
+			hint = "Failed to list programs"
+		}
+		log.Error(hint, "error", listErr)
+		return exitCode(listErr)
+	}
+	return ExitOK
+}
+
+// listPrograms lists the programs visible to each profile. A program
+// visible to several profiles is listed once, as private or followed if it is
+// for any of them.
+func listPrograms(filter rescope.ProgramFilter, profiles []*rescope.Options) ([]common.ProgramSummary, error) {
+	var programs []common.ProgramSummary
+	var errs []error
+	index := make(map[string]int)
+
+	for _, opts := range profiles {
+		listed, err := rescope.ListPrograms(filter, opts)
+		if err != nil {
+			errs = append(errs, err)
+		}
+
+		for _, program := range listed {
+			i, ok := index[program.URL]
+			if !ok {
+				index[program.URL] = len(programs)
+				programs = append(programs, program)
+				continue
+			}
+			programs[i].Private = programs[i].Private || program.Private
+			programs[i].Following = programs[i].Following || program.Following
+		}
+	}
+	return programs, errors.Join(errs...)
+}
+
+// accountProgramURLs returns the URLs of the programs to fetch with
+// --all-programs, --all-programs-public or --followed-programs, listed on the
+// platforms each profile has a token for. --all-programs only takes private
+// programs, as the public directory of a platform runs into the thousands.
+// Closed programs are skipped.
+func (cli *CLI) accountProgramURLs(profiles []*rescope.Options) ([]string, error) {
+	var urls []string
+	var errs []error
+	listed := false
+
+	for _, opts := range profiles {
+		filter := rescope.ProgramFilter{
+			PrivateOnly:  !cli.AllProgramsPublic && !cli.FollowedOnly,
+			FollowedOnly: cli.FollowedOnly,
+		}
+		for _, platform := range []struct{ name, token string }{
+			{"HackerOne", opts.AuthHackerOne},
+			{"Bugcrowd", opts.AuthBugcrowd},
+			{"Intigriti", opts.AuthIntigriti},
+			{"YesWeHack", opts.AuthYesWeHack},
+		} {
+			if platform.token != "" {
+				filter.Platforms = append(filter.Platforms, platform.name)
+			}
+		}
+
+		if len(filter.Platforms) == 0 {
+			continue
+		}
+		listed = true
+
+		programs, err := rescope.ListPrograms(filter, opts)
+		if err != nil {
+			errs = append(errs, err)
+		}
+
+		count := 0
+		for _, program := range programs {
+			if program.Status != common.ProgramClosed {
+				urls = sliceutil.AppendUnique(urls, program.URL)
+				count++
+			}
+		}
+		log.Info("Listed programs to fetch", "profile", opts.Profile, "platforms", strings.Join(filter.Platforms, ","), "programs", count)
+	}
+
+	if !listed {
+		return nil, fmt.Errorf("%w: --all-programs, --all-programs-public and --followed-programs list the programs of the platforms given an --auth-<platform> token", common.ErrAuthRequired)
+	}
+	return urls, errors.Join(errs...)
+}
+
+func getProgramsURLOutput(programs []common.ProgramSummary) string {
+	var urls []string
+	for _, program := range programs {
+		urls = append(urls, program.URL)
+	}
+	return strings.Join(urls, "\n")
+}
+
+func getProgramsJsonOutput(programs []common.ProgramSummary) (string, error) {
+	if programs == nil {
+		programs = []common.ProgramSummary{}
+	}
+
+	jsonData, err := json.MarshalIndent(programs, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize programs to JSON: %w", err)
+	}
+	return string(jsonData), nil
+}
+
+func getProgramsTableOutput(programs []common.ProgramSummary) string {
+	if len(programs) == 0 {
+		return ""
+	}
+
+	var builder strings.Builder
+	w := tabwriter.NewWriter(&builder, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PLATFORM\tNAME\tTYPE\tBOUNTY\tSTATUS\tACCESS\tURL")
+
+	for _, program := range programs {
+		access := "public"
+		if program.Private {
+			access = "private"
+		}
+		if program.Following {
+			access += ", following"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", program.Platform, program.Name, program.Type, formatBounty(program), program.Status, access, program.URL)
+	}
+
+	w.Flush()
+	return strings.TrimRight(builder.String(), "\n")
+}
+
+// formatBounty returns the bounty range of a program, e.g. "50-5000 EUR"
+func formatBounty(program common.ProgramSummary) string {
+	if !program.Bounty {
+		return "-"
+	}
+
+	amount := func(value float64) string {
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+
+	var bounty string
+	switch {
+	case program.MaxBounty == 0:
+		return "yes"
+	case program.MinBounty == 0:
+		bounty = "up to " + amount(program.MaxBounty)
+	default:
+		bounty = amount(program.MinBounty) + "-" + amount(program.MaxBounty)
+	}
+
+	if program.Currency != "" {
+		bounty += " " + program.Currency
+	}
+	return bounty
+}
+
+// isTerminal reports whether f is a terminal rather than a pipe or file
+func isTerminal(f *os.File) bool {
+	stat, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return stat.Mode()&os.ModeCharDevice != 0
+}
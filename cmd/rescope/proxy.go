@@ -0,0 +1,251 @@
+package main
+
+import (
+	"fmt"
+	"net/netip"
+	"regexp"
+	"strings"
+
+	"github.com/root4loot/goutils/log"
+	"github.com/root4loot/rescope/config"
+	"github.com/root4loot/rescope/pkg/common"
+	"github.com/root4loot/rescope/pkg/normalize"
+	"gopkg.in/yaml.v3"
+)
+
+var defaultSchemePorts = map[string]string{"http": "80", "https": "443"}
+
+// getMitmproxyOutput returns a mitmproxy options file. mitmproxy matches
+// allow_hosts against "host:port", so paths are dropped from includes and
+// excludes are folded into each allow pattern as a negative lookahead.
+// Without any includes, excludes are emitted as ignore_hosts instead.
+func getMitmproxyOutput(result *common.Result) (string, error) {
+	var options config.MitmproxyOptions
+	var excludes []string
+
+	for _, entry := range parseScopeEntries(result.OutScope) {
+		if entry.Path != "" {
+			log.Warn("Skipping exclude with path, mitmproxy only matches hosts", "entry", entry.Raw)
+			continue
+		}
+		if regex, ok := hostPortRegex(entry); ok {
+			excludes = append(excludes, regex)
+		}
+	}
+
+	lookahead := ""
+	if len(excludes) > 0 {
+		lookahead = "(?!(?:" + strings.Join(excludes, "|") + ")$)"
+	}
+
+	for _, entry := range parseScopeEntries(result.InScope) {
+		if regex, ok := hostPortRegex(entry); ok {
+			options.AllowHosts = append(options.AllowHosts, "^"+lookahead+regex+"$")
+		}
+	}
+
+	if len(options.AllowHosts) == 0 {
+		for _, regex := range excludes {
+			options.IgnoreHosts = append(options.IgnoreHosts, "^"+regex+"$")
+		}
+	}
+
+	output, err := yaml.Marshal(options)
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize mitmproxy options to YAML: %w", err)
+	}
+
+	return strings.TrimRight(string(output), "\n"), nil
+}
+
+// getSquidOutput returns Squid ACLs that deny out-of-scope destinations
+// before allowing in-scope ones, and deny everything else
+func getSquidOutput(result *common.Result) (string, error) {
+	var builder strings.Builder
+	var allow, deny []string
+
+	writeACLs := func(entries []normalize.Entry, prefix string) []string {
+		var names []string
+		var domains, regexes, networks []string
+
+		for i, entry := range entries {
+			switch {
+			case entry.Kind == normalize.KindIP || entry.Kind == normalize.KindCIDR:
+				networks = append(networks, entry.Host)
+			case entry.Port != "" || entry.Path != "" || entry.Scheme != "":
+				// compound rule, all ACLs on one http_access line must match
+				name := fmt.Sprintf("%s_%d", prefix, i)
+				fmt.Fprintf(&builder, "acl %s_host %s\n", name, squidHostACL(entry.Host))
+				rule := []string{name + "_host"}
+
+				port := entry.Port
+				if port == "" {
+					port = defaultSchemePorts[entry.Scheme]
+				}
+				if port != "" {
+					fmt.Fprintf(&builder, "acl %s_port port %s\n", name, port)
+					rule = append(rule, name+"_port")
+				}
+				if entry.Path != "" {
+					fmt.Fprintf(&builder, "acl %s_path urlpath_regex ^%s\n", name, regexp.QuoteMeta(entry.Path))
+					rule = append(rule, name+"_path")
+				}
+				names = append(names, strings.Join(rule, " "))
+			case strings.Contains(entry.Host, "*"):
+				regexes = append(regexes, "^"+posixRegex(normalize.HostRegex(entry.Host))+"$")
+			default:
+				domains = append(domains, strings.Trim(entry.Host, "[]"))
+			}
+		}
+
+		if len(domains) > 0 {
+			fmt.Fprintf(&builder, "acl %s_domains dstdomain %s\n", prefix, strings.Join(domains, " "))
+			names = append(names, prefix+"_domains")
+		}
+		if len(regexes) > 0 {
+			fmt.Fprintf(&builder, "acl %s_wildcards dstdom_regex -i %s\n", prefix, strings.Join(regexes, " "))
+			names = append(names, prefix+"_wildcards")
+		}
+		if len(networks) > 0 {
+			fmt.Fprintf(&builder, "acl %s_networks dst %s\n", prefix, strings.Join(networks, " "))
+			names = append(names, prefix+"_networks")
+		}
+
+		return names
+	}
+
+	deny = writeACLs(parseScopeEntries(result.OutScope), "rescope_out")
+	allow = writeACLs(parseScopeEntries(result.InScope), "rescope_in")
+
+	builder.WriteString("\n")
+	for _, name := range deny {
+		fmt.Fprintf(&builder, "http_access deny %s\n", name)
+	}
+	for _, name := range allow {
+		fmt.Fprintf(&builder, "http_access allow %s\n", name)
+	}
+	builder.WriteString("http_access deny all")
+
+	return builder.String(), nil
+}
+
+// getPacOutput returns a proxy auto-config file that sends in-scope traffic
+// through proxy and everything else directly
+func getPacOutput(result *common.Result, proxy string) (string, error) {
+	var builder strings.Builder
+
+	builder.WriteString("function FindProxyForURL(url, host) {\n")
+	builder.WriteString("  host = host.toLowerCase();\n\n")
+
+	for _, entry := range parseScopeEntries(result.OutScope) {
+		if condition, ok := pacCondition(entry); ok {
+			fmt.Fprintf(&builder, "  if (%s) return \"DIRECT\";\n", condition)
+		}
+	}
+
+	builder.WriteString("\n")
+	for _, entry := range parseScopeEntries(result.InScope) {
+		if condition, ok := pacCondition(entry); ok {
+			fmt.Fprintf(&builder, "  if (%s) return \"PROXY %s\";\n", condition, proxy)
+		}
+	}
+
+	builder.WriteString("\n  return \"DIRECT\";\n}")
+
+	return builder.String(), nil
+}
+
+// hostPortRegex returns a regex matching "host:port" for entry
+func hostPortRegex(entry normalize.Entry) (string, bool) {
+	var host string
+	switch entry.Kind {
+	case normalize.KindIP, normalize.KindCIDR:
+		regex, err := normalize.CIDRRegex(entry.Host)
+		if err != nil {
+			log.Warn("Skipping entry not expressible as a regex", "entry", entry.Raw, "error", err)
+			return "", false
+		}
+		host = regex
+	default:
+		host = normalize.HostRegex(strings.Trim(entry.Host, "[]"))
+	}
+
+	port := entry.Port
+	if port == "" {
+		port = defaultSchemePorts[entry.Scheme]
+	}
+	if port == "" {
+		return host + `(?::\d+)?`, true
+	}
+
+	return host + ":" + port, true
+}
+
+func squidHostACL(host string) string {
+	if strings.Contains(host, "*") {
+		return "dstdom_regex -i ^" + posixRegex(normalize.HostRegex(host)) + "$"
+	}
+	return "dstdomain " + strings.Trim(host, "[]")
+}
+
+// posixRegex converts the non-capturing groups produced by HostRegex to plain
+// groups, as Squid uses POSIX extended regular expressions
+func posixRegex(regex string) string {
+	return strings.ReplaceAll(regex, "(?:", "(")
+}
+
+func pacCondition(entry normalize.Entry) (string, bool) {
+	switch entry.Kind {
+	case normalize.KindIP, normalize.KindCIDR:
+		prefix, err := netip.ParsePrefix(entry.Host)
+		if err != nil {
+			addr, err := netip.ParseAddr(entry.Host)
+			if err != nil {
+				return "", false
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		if !prefix.Addr().Is4() {
+			log.Warn("Skipping IPv6 entry not supported by PAC isInNet", "entry", entry.Raw)
+			return "", false
+		}
+		mask := netip.AddrFrom4([4]byte(netMask(prefix.Bits())))
+		return fmt.Sprintf("isInNet(host, %q, %q)", prefix.Masked().Addr().String(), mask.String()), true
+	}
+
+	var hostCondition string
+	if strings.Contains(entry.Host, "*") {
+		hostCondition = fmt.Sprintf("shExpMatch(host, %q)", entry.Host)
+	} else {
+		hostCondition = fmt.Sprintf("host === %q", strings.Trim(entry.Host, "[]"))
+	}
+
+	if entry.Kind != normalize.KindURL && entry.Port == "" {
+		return hostCondition, true
+	}
+
+	// browsers only pass the origin of HTTPS URLs to PAC scripts, so paths
+	// only take effect for plain HTTP
+	scheme := entry.Scheme
+	if scheme == "" {
+		scheme = "*"
+	}
+	port := ""
+	if entry.Port != "" {
+		port = ":" + entry.Port
+	}
+	pattern := fmt.Sprintf("%s://%s%s%s*", scheme, entry.Host, port, entry.Path)
+	if entry.Path == "" {
+		pattern = fmt.Sprintf("%s://%s%s/*", scheme, entry.Host, port)
+	}
+
+	return fmt.Sprintf("%s && shExpMatch(url, %q)", hostCondition, pattern), true
+}
+
+func netMask(bits int) []byte {
+	mask := make([]byte, 4)
+	for i := 0; i < bits; i++ {
+		mask[i/8] |= 1 << (7 - uint(i%8))
+	}
+	return mask
+}
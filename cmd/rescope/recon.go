@@ -0,0 +1,128 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/root4loot/goutils/log"
+	"github.com/root4loot/goutils/sliceutil"
+	"github.com/root4loot/rescope/pkg/common"
+	"github.com/root4loot/rescope/pkg/normalize"
+)
+
+// getNmapOutput returns an nmap target list (-iL) of in-scope hosts, IPs and
+// CIDRs. Wildcards can't be scanned and are skipped.
+func getNmapOutput(result *common.Result) string {
+	return strings.Join(nmapTargets(parseScopeEntries(result.InScope)), "\n")
+}
+
+// getNmapExcludeOutput returns an nmap --excludefile list of out-of-scope
+// hosts, IPs and CIDRs. Excludes limited to a port or path are skipped, as
+// nmap can only exclude whole hosts.
+func getNmapExcludeOutput(result *common.Result) string {
+	var entries []normalize.Entry
+	for _, entry := range parseScopeEntries(result.OutScope) {
+		if entry.Port != "" || entry.Path != "" {
+			log.Warn("Skipping exclude with port or path, nmap can only exclude whole hosts", "entry", entry.Raw)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return strings.Join(nmapTargets(entries), "\n")
+}
+
+// getMasscanOutput returns a masscan configuration with a range for each
+// in-scope IP or CIDR and an exclude for each out-of-scope one. masscan does
+// not resolve hostnames, so those are skipped.
+func getMasscanOutput(result *common.Result) string {
+	var builder strings.Builder
+	builder.WriteString("# generated by rescope, set ports with --ports or a ports = line\n")
+	skipped := 0
+
+	for _, entry := range parseScopeEntries(result.InScope) {
+		if entry.Kind == normalize.KindIP || entry.Kind == normalize.KindCIDR {
+			builder.WriteString("range = " + entry.Host + "\n")
+		} else {
+			skipped++
+		}
+	}
+
+	for _, entry := range parseScopeEntries(result.OutScope) {
+		if entry.Kind == normalize.KindIP || entry.Kind == normalize.KindCIDR {
+			builder.WriteString("exclude = " + entry.Host + "\n")
+		} else {
+			skipped++
+		}
+	}
+
+	if skipped > 0 {
+		log.Warn("Skipped hostnames, masscan only accepts IPs", "count", skipped)
+	}
+
+	return strings.TrimRight(builder.String(), "\n")
+}
+
+// getHostsOutput returns the concrete in-scope hosts and URLs, e.g. for httpx
+// or nuclei. Wildcards are skipped, as are CIDRs unless --expand-ip-ranges
+// turned them into individual IPs, and anything covered by an exclude.
+func getHostsOutput(result *common.Result) string {
+	excludes := parseScopeEntries(result.OutScope)
+	var hosts []string
+
+	for _, entry := range parseScopeEntries(result.InScope) {
+		if entry.Kind == normalize.KindWildcard || entry.Kind == normalize.KindCIDR || strings.Contains(entry.Host, "*") {
+			continue
+		}
+
+		excluded := false
+		for _, exclude := range excludes {
+			if normalize.Covers(exclude, entry) {
+				excluded = true
+				break
+			}
+		}
+
+		if !excluded {
+			hosts = sliceutil.AppendUnique(hosts, entry.String())
+		}
+	}
+
+	return strings.Join(hosts, "\n")
+}
+
+// getRootsOutput returns the domains below in-scope wildcards, e.g. for
+// subdomain enumeration. *.example.com and api.*.example.com both yield
+// example.com.
+func getRootsOutput(result *common.Result) string {
+	var roots []string
+
+	for _, entry := range parseScopeEntries(result.InScope) {
+		if !strings.Contains(entry.Host, "*") {
+			continue
+		}
+
+		labels := strings.Split(entry.Host, ".")
+		for i := len(labels) - 1; i >= 0; i-- {
+			if strings.Contains(labels[i], "*") {
+				root := strings.Join(labels[i+1:], ".")
+				if strings.Contains(root, ".") {
+					roots = sliceutil.AppendUnique(roots, root)
+				}
+				break
+			}
+		}
+	}
+
+	return strings.Join(roots, "\n")
+}
+
+func nmapTargets(entries []normalize.Entry) []string {
+	var targets []string
+	for _, entry := range entries {
+		if strings.Contains(entry.Host, "*") {
+			log.Warn("Skipping wildcard, nmap can't scan wildcards", "entry", entry.Raw)
+			continue
+		}
+		targets = sliceutil.AppendUnique(targets, strings.Trim(entry.Host, "[]"))
+	}
+	return targets
+}
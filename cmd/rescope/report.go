@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/root4loot/rescope/pkg/common"
+	"github.com/root4loot/rescope/pkg/scopefile"
+	"gopkg.in/yaml.v3"
+)
+
+// getYamlOutput returns result as YAML with the same structure and field
+// names as the JSON output
+func getYamlOutput(result *common.Result) (string, error) {
+	jsonData, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize Result to JSON: %w", err)
+	}
+
+	// JSON is valid YAML, decoding into a node keeps the field order
+	var node yaml.Node
+	if err := yaml.Unmarshal(jsonData, &node); err != nil {
+		return "", fmt.Errorf("failed to convert JSON to YAML: %w", err)
+	}
+	clearStyle(&node)
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return "", fmt.Errorf("failed to serialize Result to YAML: %w", err)
+	}
+
+	return strings.TrimRight(buf.String(), "\n"), nil
+}
+
+// getScopeFileOutput returns programs as a scope file, in YAML or JSON
+func getScopeFileOutput(programs []*common.Result, asJSON bool) (string, error) {
+	file := scopefile.FromResults(programs)
+
+	encode := scopefile.EncodeYAML
+	if asJSON {
+		encode = scopefile.EncodeJSON
+	}
+
+	data, err := encode(file)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(string(data), "\n"), nil
+}
+
+// clearStyle drops the flow and quoting styles picked up from the JSON input
+// so the node is written as block YAML
+func clearStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearStyle(child)
+	}
+}
+
+// getCsvOutput returns one row per asset of each program
+func getCsvOutput(programs []*common.Result) (string, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	records := [][]string{{"program", "platform", "scope", "identifier", "type", "category", "bounty_eligible"}}
+	for _, result := range programs {
+		for _, inScope := range []bool{true, false} {
+			for _, asset := range scopeAssets(result, inScope) {
+				records = append(records, []string{
+					result.ProgramDetails.ProgramName,
+					result.ProgramDetails.Platform,
+					scopeLabel(inScope),
+					asset.Identifier,
+					asset.Type,
+					asset.Category,
+					eligibilityLabel(asset.BountyEligible),
+				})
+			}
+		}
+	}
+
+	if err := writer.WriteAll(records); err != nil {
+		return "", fmt.Errorf("failed to write CSV: %w", err)
+	}
+
+	return strings.TrimRight(buf.String(), "\n"), nil
+}
+
+// getMarkdownOutput returns a report with a section per program listing its
+// in-scope and out-of-scope assets
+func getMarkdownOutput(programs []*common.Result) string {
+	var builder strings.Builder
+	builder.WriteString("# Scope Report\n")
+
+	for _, result := range programs {
+		details := result.ProgramDetails
+
+		builder.WriteString("\n## ")
+		builder.WriteString(markdownEscape(programTitle(details)))
+		builder.WriteString("\n")
+
+		if details.PolicyURL != "" || details.FetchedAt != "" {
+			builder.WriteString("\n")
+		}
+		if details.PolicyURL != "" {
+			fmt.Fprintf(&builder, "- Policy: <%s>\n", details.PolicyURL)
+		}
+		if details.FetchedAt != "" {
+			fmt.Fprintf(&builder, "- Fetched: %s\n", details.FetchedAt)
+		}
+
+		writeMarkdownTable(&builder, "In Scope", scopeAssets(result, true))
+		writeMarkdownTable(&builder, "Out of Scope", scopeAssets(result, false))
+	}
+
+	return strings.TrimRight(builder.String(), "\n")
+}
+
+func writeMarkdownTable(builder *strings.Builder, title string, assets []common.Asset) {
+	fmt.Fprintf(builder, "\n### %s\n\n", title)
+
+	if len(assets) == 0 {
+		builder.WriteString("_None_\n")
+		return
+	}
+
+	builder.WriteString("| Identifier | Type | Category | Bounty |\n")
+	builder.WriteString("|---|---|---|---|\n")
+	for _, asset := range assets {
+		fmt.Fprintf(builder, "| `%s` | %s | %s | %s |\n",
+			strings.NewReplacer("`", "", "|", `\|`).Replace(asset.Identifier),
+			markdownEscape(asset.Type),
+			markdownEscape(asset.Category),
+			eligibilityLabel(asset.BountyEligible))
+	}
+}
+
+// programTitle returns the name a program is listed under in reports
+func programTitle(details common.BugBountyProgram) string {
+	switch {
+	case details.ProgramName == "":
+		return "Custom Scope"
+	case details.Platform == "":
+		return details.ProgramName
+	default:
+		return fmt.Sprintf("%s (%s)", details.ProgramName, details.Platform)
+	}
+}
+
+func scopeLabel(inScope bool) string {
+	if inScope {
+		return "in"
+	}
+	return "out"
+}
+
+func eligibilityLabel(eligible *bool) string {
+	switch {
+	case eligible == nil:
+		return ""
+	case *eligible:
+		return "yes"
+	default:
+		return "no"
+	}
+}
+
+func markdownEscape(s string) string {
+	return strings.NewReplacer("|", `\|`, "*", `\*`, "_", `\_`, "`", "\\`").Replace(s)
+}
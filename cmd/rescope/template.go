@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/root4loot/rescope/pkg/common"
+	"github.com/root4loot/rescope/pkg/normalize"
+)
+
+// templateData is passed to user templates. It embeds the result, so
+// .ProgramDetails, .InScope, .OutScope and .Derived are available as well.
+type templateData struct {
+	*common.Result
+	InScopeAssets  []common.Asset
+	OutScopeAssets []common.Asset
+}
+
+var templateFuncs = template.FuncMap{
+	"regexEscape":   regexp.QuoteMeta,
+	"wildcardRegex": wildcardRegex,
+	"expandIPs":     expandIPs,
+	"json":          toJSON,
+	"join":          strings.Join,
+	"lower":         strings.ToLower,
+	"upper":         strings.ToUpper,
+	"replace":       strings.ReplaceAll,
+	"hasPrefix":     strings.HasPrefix,
+	"hasSuffix":     strings.HasSuffix,
+	"contains":      strings.Contains,
+}
+
+// getTemplateOutput renders result through the Go text/template in file
+func getTemplateOutput(result *common.Result, file string) (string, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to read template: %w", err)
+	}
+
+	tmpl, err := template.New(file).Funcs(templateFuncs).Parse(string(content))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	data := templateData{
+		Result:         result,
+		InScopeAssets:  scopeAssets(result, true),
+		OutScopeAssets: scopeAssets(result, false),
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render template: %w", err)
+	}
+
+	return strings.TrimRight(buf.String(), "\n"), nil
+}
+
+// scopeAssets returns the in-scope or out-of-scope entries of result as
+// assets. Platform metadata is taken from result.Assets where available, the
+// type is otherwise set from the parsed kind.
+func scopeAssets(result *common.Result, inScope bool) []common.Asset {
+	items := result.OutScope
+	if inScope {
+		items = result.InScope
+	}
+
+	known := make(map[string]common.Asset)
+	for _, asset := range result.Assets {
+		if asset.InScope == inScope {
+			known[asset.Identifier] = asset
+		}
+	}
+
+	assets := make([]common.Asset, 0, len(items))
+	for _, item := range items {
+		asset, ok := known[item]
+		if !ok {
+			asset = common.Asset{Identifier: item, InScope: inScope}
+			if entry, err := normalize.Parse(item); err == nil {
+				asset.Type = string(entry.Kind)
+			}
+		}
+		assets = append(assets, asset)
+	}
+	return assets
+}
+
+// wildcardRegex returns an unanchored regex matching the host, IP or CIDR of
+// a scope entry
+func wildcardRegex(item string) string {
+	entry, err := normalize.Parse(item)
+	if err != nil {
+		return regexp.QuoteMeta(item)
+	}
+
+	if entry.Kind == normalize.KindIP || entry.Kind == normalize.KindCIDR {
+		if regex, err := normalize.CIDRRegex(entry.Host); err == nil {
+			return regex
+		}
+		return regexp.QuoteMeta(entry.Host)
+	}
+
+	return normalize.HostRegex(entry.Host)
+}
+
+func expandIPs(item string) ([]string, error) {
+	return ipRangeToIPs([]string{item})
+}
+
+func toJSON(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
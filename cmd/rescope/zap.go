@@ -0,0 +1,202 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/root4loot/goutils/log"
+	"github.com/root4loot/goutils/sliceutil"
+	"github.com/root4loot/rescope/config"
+	"github.com/root4loot/rescope/pkg/common"
+	"github.com/root4loot/rescope/pkg/normalize"
+	"gopkg.in/yaml.v3"
+)
+
+// zapOptions controls the ZAP context and automation plan outputs
+type zapOptions struct {
+	ContextName string // defaults to the program name
+	Description string
+	TechInclude []string // defaults to zapDefaultTech
+	TechExclude []string
+	PerProgram  bool // one context per program in the automation plan
+}
+
+var zapDefaultTech = []string{
+	"Db", "Db.Firebird", "Db.HypersonicSQL", "Db.IBM DB2", "Db.Microsoft Access", "Db.Microsoft SQL Server",
+	"Db.MySQL", "Db.Oracle", "Db.PostgreSQL", "Db.SAP MaxDB", "Db.SQLite", "Db.Sybase",
+	"Language", "Language.ASP", "Language.C", "Language.PHP", "Language.XML",
+	"OS", "OS.Linux", "OS.MacOS", "OS.Windows",
+	"SCM", "SCM.Git", "SCM.SVN",
+	"WS", "WS.Apache", "WS.IIS", "WS.Tomcat",
+}
+
+// getZapOutput returns the scope as a ZAP context file
+func getZapOutput(result *common.Result, programs []*common.Result, opts zapOptions) (string, error) {
+	var config config.ZapConfig
+	config.Context.Name = zapContextName(opts, result, programs)
+	config.Context.Desc = opts.Description
+	config.Context.Inscope = "true"
+
+	config.Context.Forceduser = "-1"
+	config.Context.Authentication.Type = 0
+	config.Context.Authentication.Strategy = "EACH_RESP"
+	config.Context.Authentication.Pollurl = ""
+	config.Context.Authentication.Polldata = ""
+	config.Context.Authentication.Pollfreq = 60
+	config.Context.Authentication.Pollunits = "REQUESTS"
+	config.Context.Session.Type = 0
+	config.Context.Authorization.Type = 0
+	config.Context.Authorization.Basic.Header = ""
+	config.Context.Authorization.Basic.Body = ""
+	config.Context.Authorization.Basic.Logic = "AND"
+	config.Context.Authorization.Basic.Code = -1
+
+	config.Context.Incregexes = zapRegexes(result.InScope)
+	config.Context.Excregexes = zapRegexes(result.OutScope)
+	config.Context.Tech.Include, config.Context.Tech.Exclude = zapTech(opts)
+
+	config.Context.Urlparser.Class = "org.zaproxy.zap.model.StandardParameterParser"
+	config.Context.Urlparser.Config = "{\"kvps\":\"&\",\"kvs\":\"=\",\"struct\":[]}"
+	config.Context.Postparser.Class = "org.zaproxy.zap.model.StandardParameterParser"
+	config.Context.Postparser.Config = "{\"kvps\":\"&\",\"kvs\":\"=\",\"struct\":[]}"
+
+	output, err := xml.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize ZAP scope to XML: %w", err)
+	}
+
+	return xml.Header + string(output), nil
+}
+
+// getZapPlanOutput returns a ZAP Automation Framework plan that defines the
+// scope as a context (or one per program) and spiders it
+func getZapPlanOutput(result *common.Result, programs []*common.Result, opts zapOptions) (string, error) {
+	var plan config.ZapPlan
+	plan.Env.Parameters.FailOnError = true
+	plan.Env.Parameters.ProgressToStdout = true
+
+	addContext := func(name string, result *common.Result) {
+		plan.Env.Contexts = append(plan.Env.Contexts, config.ZapPlanContext{
+			Name:         name,
+			URLs:         zapStartURLs(result),
+			IncludePaths: zapRegexes(result.InScope),
+			ExcludePaths: zapRegexes(result.OutScope),
+		})
+		plan.Jobs = append(plan.Jobs, config.ZapJob{
+			Type:       "spider",
+			Parameters: map[string]string{"context": name},
+		})
+	}
+
+	if opts.PerProgram && len(programs) > 1 {
+		for _, program := range programs {
+			addContext(zapContextName(zapOptions{}, program, []*common.Result{program}), program)
+		}
+	} else {
+		addContext(zapContextName(opts, result, programs), result)
+	}
+
+	plan.Jobs = append(plan.Jobs, config.ZapJob{Type: "passiveScan-wait"})
+
+	output, err := yaml.Marshal(plan)
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize ZAP plan to YAML: %w", err)
+	}
+
+	return strings.TrimRight(string(output), "\n"), nil
+}
+
+// zapContextName returns the configured context name, or the program name if
+// the result holds a single program
+func zapContextName(opts zapOptions, result *common.Result, programs []*common.Result) string {
+	switch {
+	case opts.ContextName != "":
+		return opts.ContextName
+	case len(programs) <= 1 && result.ProgramDetails.ProgramName != "":
+		return result.ProgramDetails.ProgramName
+	default:
+		return AppName
+	}
+}
+
+// zapTech returns the technologies to include and exclude in the context
+func zapTech(opts zapOptions) (include, exclude []string) {
+	candidates := opts.TechInclude
+	if len(candidates) == 0 {
+		candidates = zapDefaultTech
+	}
+
+	for _, tech := range candidates {
+		if !sliceutil.Contains(opts.TechExclude, tech) {
+			include = append(include, tech)
+		}
+	}
+
+	return include, opts.TechExclude
+}
+
+// zapRegexes returns the URL regexes ZAP uses to match items. IPs and CIDRs
+// are matched with a regex rather than expanded into individual addresses.
+func zapRegexes(items []string) []string {
+	var regexes []string
+	for _, entry := range parseScopeEntries(items) {
+		if regex, ok := zapRegex(entry); ok {
+			regexes = append(regexes, regex)
+		}
+	}
+	return regexes
+}
+
+func zapRegex(entry normalize.Entry) (string, bool) {
+	scheme := entry.Scheme
+	if scheme == "" {
+		scheme = "https?"
+	}
+
+	var host string
+	switch entry.Kind {
+	case normalize.KindIP, normalize.KindCIDR:
+		regex, err := normalize.CIDRRegex(entry.Host)
+		if err != nil {
+			log.Warn("Skipping entry not expressible as a ZAP regex", "entry", entry.Raw, "error", err)
+			return "", false
+		}
+		host = regex
+	default:
+		host = normalize.HostRegex(entry.Host)
+	}
+
+	port := `(?::\d+)?`
+	if entry.Port != "" {
+		port = ":" + entry.Port
+	}
+
+	path := `(?:[/?#].*)?`
+	if entry.Path != "" {
+		path = strings.ReplaceAll(regexp.QuoteMeta(entry.Path), `\*`, ".*") + ".*"
+	}
+
+	return "^" + scheme + "://" + host + port + path + "$", true
+}
+
+// zapStartURLs returns the URLs the automation plan starts from: concrete
+// in-scope hosts and URLs, and the root domains of wildcards
+func zapStartURLs(result *common.Result) []string {
+	var urls []string
+	for _, target := range strings.Split(getHostsOutput(result)+"\n"+getRootsOutput(result), "\n") {
+		if target == "" {
+			continue
+		}
+		if !strings.Contains(target, "://") {
+			target = "https://" + target
+		}
+		urls = sliceutil.AppendUnique(urls, target)
+	}
+
+	if len(urls) == 0 {
+		log.Warn("No concrete in-scope URL to start the ZAP plan from, add one under env.contexts[].urls")
+	}
+	return urls
+}
@@ -25,3 +25,21 @@ type BurpInclude struct {
 	Port     string `json:"port"`
 	Protocol string `json:"protocol"`
 }
+
+// BurpSimpleConfig is the scope in Burp's simple (non-advanced) mode, which
+// matches URL prefixes
+type BurpSimpleConfig struct {
+	Target struct {
+		Scope struct {
+			AdvancedMode bool         `json:"advancedMode"`
+			Exclude      []BurpPrefix `json:"exclude"`
+			Include      []BurpPrefix `json:"include"`
+		} `json:"scope"`
+	} `json:"target"`
+}
+
+type BurpPrefix struct {
+	Enabled           bool   `json:"enabled"`
+	IncludeSubdomains bool   `json:"include_subdomains"`
+	Prefix            string `json:"prefix"`
+}
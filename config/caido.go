@@ -0,0 +1,9 @@
+package config
+
+// CaidoScope is a Caido scope preset. Allowlist and denylist entries are host
+// glob patterns.
+type CaidoScope struct {
+	Name      string   `json:"name"`
+	Allowlist []string `json:"allowlist"`
+	Denylist  []string `json:"denylist"`
+}
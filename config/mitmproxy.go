@@ -0,0 +1,8 @@
+package config
+
+// MitmproxyOptions holds the scope related mitmproxy options. allow_hosts and
+// ignore_hosts are mutually exclusive in mitmproxy, so only one is set.
+type MitmproxyOptions struct {
+	AllowHosts  []string `yaml:"allow_hosts,omitempty"`
+	IgnoreHosts []string `yaml:"ignore_hosts,omitempty"`
+}
@@ -12,6 +12,7 @@ type ZapConfig struct {
 		Excregexes []string `xml:"excregexes,omitempty"`
 		Tech       struct {
 			Include []string `xml:"include"`
+			Exclude []string `xml:"exclude,omitempty"`
 		} `xml:"tech"`
 		Urlparser struct {
 			Class  string `xml:"class"`
@@ -44,3 +45,31 @@ type ZapConfig struct {
 		} `xml:"authorization"`
 	} `xml:"context"`
 }
+
+// ZapPlan is a ZAP Automation Framework plan
+type ZapPlan struct {
+	Env  ZapPlanEnv `yaml:"env"`
+	Jobs []ZapJob   `yaml:"jobs"`
+}
+
+type ZapPlanEnv struct {
+	Contexts   []ZapPlanContext `yaml:"contexts"`
+	Parameters struct {
+		FailOnError      bool `yaml:"failOnError"`
+		FailOnWarning    bool `yaml:"failOnWarning"`
+		ProgressToStdout bool `yaml:"progressToStdout"`
+	} `yaml:"parameters"`
+}
+
+type ZapPlanContext struct {
+	Name         string   `yaml:"name"`
+	URLs         []string `yaml:"urls"`
+	IncludePaths []string `yaml:"includePaths,omitempty"`
+	ExcludePaths []string `yaml:"excludePaths,omitempty"`
+}
+
+type ZapJob struct {
+	Type       string            `yaml:"type"`
+	Name       string            `yaml:"name,omitempty"`
+	Parameters map[string]string `yaml:"parameters,omitempty"`
+}
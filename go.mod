@@ -3,19 +3,23 @@ module github.com/root4loot/rescope
 go 1.23.0
 
 require (
+	github.com/ProtonMail/go-crypto v1.1.6
 	github.com/pkg/errors v0.9.1
 	github.com/root4loot/goutils v0.0.0-20241005165219-eb1bcf33780f
 	github.com/root4loot/scope v0.0.0-20240904154416-13aa57c33326
+	github.com/sirupsen/logrus v1.9.3
 	github.com/stretchr/testify v1.8.4
+	golang.org/x/net v0.34.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
+	github.com/cloudflare/circl v1.3.7 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	github.com/sirupsen/logrus v1.9.3 // indirect
 	github.com/yl2chen/cidranger v1.0.2 // indirect
-	golang.org/x/crypto v0.19.0 // indirect
-	golang.org/x/sys v0.17.0 // indirect
-	golang.org/x/term v0.17.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
+	golang.org/x/crypto v0.32.0 // indirect
+	golang.org/x/sys v0.29.0 // indirect
+	golang.org/x/term v0.28.0 // indirect
+	golang.org/x/text v0.21.0 // indirect
 )
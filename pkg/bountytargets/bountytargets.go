@@ -0,0 +1,219 @@
+// Package bountytargets reads the per-platform program dumps published by the
+// bounty-targets-data project (hackerone_data.json, bugcrowd_data.json,
+// intigriti_data.json and yeswehack_data.json), as an offline alternative to
+// fetching programs from their platforms.
+package bountytargets
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/root4loot/goutils/domainutil"
+	"github.com/root4loot/rescope/pkg/bugbounty/bugcrowd"
+	"github.com/root4loot/rescope/pkg/bugbounty/hackerone"
+	"github.com/root4loot/rescope/pkg/bugbounty/intigriti"
+	"github.com/root4loot/rescope/pkg/bugbounty/yeswehack"
+	"github.com/root4loot/rescope/pkg/common"
+	"github.com/root4loot/rescope/pkg/normalize"
+)
+
+// Dump holds the programs of one or more dumps, with the same program
+// details and normalized scope as a live fetch
+type Dump struct {
+	Programs []*common.Result
+}
+
+// program is a program in any of the dumps. Each platform uses a subset of
+// the fields.
+type program struct {
+	ID            json.RawMessage `json:"id"` // a number on HackerOne, a slug on YesWeHack
+	Handle        string          `json:"handle"`
+	CompanyHandle string          `json:"company_handle"` // Intigriti
+	Name          string          `json:"name"`
+	URL           string          `json:"url"`
+	Targets       struct {
+		InScope    []target `json:"in_scope"`
+		OutOfScope []target `json:"out_of_scope"`
+	} `json:"targets"`
+}
+
+type target struct {
+	AssetIdentifier   string `json:"asset_identifier"` // HackerOne
+	AssetType         string `json:"asset_type"`
+	EligibleForBounty *bool  `json:"eligible_for_bounty"`
+	Instruction       string `json:"instruction"`
+	MaxSeverity       string `json:"max_severity"`
+	Target            string `json:"target"` // Bugcrowd and YesWeHack
+	URI               string `json:"uri"`
+	Endpoint          string `json:"endpoint"` // Intigriti
+	Description       string `json:"description"`
+	Type              string `json:"type"`
+}
+
+// hackerOneTypes are the HackerOne asset types kept, as in a live fetch
+var hackerOneTypes = map[string]bool{
+	"URL": true, "WILDCARD": true, "CIDR": true, "IP_ADDRESS": true, "IP": true, "IP-RANGE": true, "RANGE": true,
+}
+
+// Load reads a dump. Programs whose platform can't be told are skipped.
+func Load(data []byte) (*Dump, error) {
+	var programs []program
+	if err := json.Unmarshal(data, &programs); err != nil {
+		return nil, fmt.Errorf("failed to parse bounty-targets dump: %w", err)
+	}
+
+	dump := &Dump{}
+	for _, p := range programs {
+		details, err := p.details()
+		if err != nil {
+			continue
+		}
+
+		result := &common.Result{ProgramDetails: *details}
+		add := func(targets []target, inScope bool) {
+			for _, t := range targets {
+				asset, ok := t.asset(details.Platform)
+				if !ok {
+					continue
+				}
+				asset.InScope = inScope
+
+				if inScope {
+					result.InScope = append(result.InScope, asset.Identifier)
+				} else {
+					result.OutScope = append(result.OutScope, asset.Identifier)
+				}
+				result.Assets = append(result.Assets, asset)
+			}
+		}
+		add(p.Targets.InScope, true)
+		add(p.Targets.OutOfScope, false)
+
+		normalize.Result(result)
+		dump.Programs = append(dump.Programs, result)
+	}
+
+	if len(programs) > 0 && len(dump.Programs) == 0 {
+		return nil, fmt.Errorf("no programs of a known platform in bounty-targets dump")
+	}
+
+	return dump, nil
+}
+
+// details returns the program details a live fetch of the program would
+// have, by parsing its URL with the platform's parser
+func (p program) details() (*common.BugBountyProgram, error) {
+	var id string
+	if err := json.Unmarshal(p.ID, &id); err != nil {
+		id = ""
+	}
+
+	programURL := p.URL
+	switch {
+	case p.CompanyHandle != "" && p.Handle != "":
+		programURL = "https://app.intigriti.com/programs/" + p.CompanyHandle + "/" + p.Handle + "/detail"
+	case programURL == "" && id != "":
+		programURL = "https://yeswehack.com/programs/" + id
+	}
+
+	details, err := parseURL(programURL)
+	if err != nil {
+		return nil, err
+	}
+	details.InputURL = programURL
+	return details, nil
+}
+
+// parseURL parses a program URL with the parser of its platform
+func parseURL(rawURL string) (*common.BugBountyProgram, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	switch domainutil.GetRootDomain(u.Hostname()) {
+	case "hackerone.com":
+		return (&hackerone.HackerOne{}).ParseURL(rawURL)
+	case "bugcrowd.com":
+		return (&bugcrowd.Bugcrowd{}).ParseURL(rawURL)
+	case "intigriti.com":
+		return (&intigriti.Intigriti{}).ParseURL(rawURL)
+	case "yeswehack.com":
+		return (&yeswehack.YesWeHack{}).ParseURL(rawURL)
+	default:
+		return nil, fmt.Errorf("unsupported bug bounty platform for URL: %s", rawURL)
+	}
+}
+
+// asset returns the asset described by t. It reports false if t has no
+// identifier or is of a type a live fetch would skip.
+func (t target) asset(platform string) (common.Asset, bool) {
+	asset := common.Asset{Category: t.Type, Notes: t.Description}
+
+	switch {
+	case t.AssetIdentifier != "":
+		if !hackerOneTypes[t.AssetType] {
+			return asset, false
+		}
+		asset.Identifier = t.AssetIdentifier
+		asset.Category = t.AssetType
+		asset.BountyEligible = t.EligibleForBounty
+		asset.MaxSeverity = t.MaxSeverity
+		asset.Notes = t.Instruction
+	case t.Endpoint != "":
+		asset.Identifier = t.Endpoint
+	case t.Target != "" && (platform != "Bugcrowd" || domainutil.IsDomainName(t.Target) || t.URI == ""):
+		asset.Identifier = t.Target
+	default:
+		asset.Identifier = t.URI
+	}
+
+	asset.Identifier = strings.TrimSpace(asset.Identifier)
+	return asset, asset.Identifier != ""
+}
+
+// Find returns a copy of the program matching query, which is a program URL,
+// a handle, or business/handle. Handles are matched case-insensitively.
+func (d *Dump) Find(query string) (*common.Result, error) {
+	platform, business, handle := parseQuery(query)
+
+	for _, program := range d.Programs {
+		details := program.ProgramDetails
+		if platform != "" && details.Platform != platform {
+			continue
+		}
+		if business != "" && !strings.EqualFold(details.Business, business) {
+			continue
+		}
+		if strings.EqualFold(details.ProgramName, handle) {
+			found := *program
+			return &found, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w in bounty-targets dump: %s", common.ErrProgramNotFound, query)
+}
+
+// parseQuery splits a program URL or handle into the platform, business and
+// program handle to match
+func parseQuery(query string) (platform, business, handle string) {
+	query = strings.TrimSpace(query)
+
+	if strings.Contains(query, "://") {
+		details, err := parseURL(query)
+		if err != nil {
+			return "", "", query
+		}
+		if details.Platform == "Intigriti" {
+			business = details.Business
+		}
+		return details.Platform, business, details.ProgramName
+	}
+
+	if before, after, ok := strings.Cut(strings.Trim(query, "/"), "/"); ok {
+		return "", before, after
+	}
+	return "", "", query
+}
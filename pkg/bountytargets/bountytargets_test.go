@@ -0,0 +1,91 @@
+package bountytargets
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/root4loot/rescope/pkg/common"
+	"github.com/stretchr/testify/assert"
+)
+
+const hackerOneDump = `[{
+	"id": 13, "handle": "security", "name": "HackerOne", "url": "https://hackerone.com/security", "offers_bounties": true,
+	"targets": {
+		"in_scope": [
+			{"asset_identifier": "*.hackerone.com", "asset_type": "WILDCARD", "eligible_for_bounty": true, "eligible_for_submission": true, "instruction": "", "max_severity": "critical"},
+			{"asset_identifier": "com.hackerone.app", "asset_type": "GOOGLE_PLAY_APP_ID", "eligible_for_bounty": true, "eligible_for_submission": true}
+		],
+		"out_of_scope": [
+			{"asset_identifier": "www.hackerone.com", "asset_type": "URL", "eligible_for_bounty": false, "eligible_for_submission": false, "instruction": "Marketing site"}
+		]
+	}
+}]`
+
+func TestLoad(t *testing.T) {
+	dump, err := Load([]byte(hackerOneDump))
+	assert.NoError(t, err)
+	assert.Len(t, dump.Programs, 1)
+
+	result := dump.Programs[0]
+	assert.Equal(t, common.BugBountyProgram{
+		InputURL:    "https://hackerone.com/security",
+		Platform:    "HackerOne",
+		Business:    "security",
+		ProgramName: "security",
+		PolicyURL:   "https://hackerone.com/security",
+	}, result.ProgramDetails)
+	assert.Equal(t, []string{"*.hackerone.com"}, result.InScope, "Expected non-network assets to be skipped")
+	assert.Equal(t, []string{"www.hackerone.com"}, result.OutScope)
+	assert.Equal(t, "critical", result.Assets[0].MaxSeverity)
+	assert.Equal(t, "Marketing site", result.Assets[1].Notes)
+
+	others := `[
+		{"name": "Tesla", "url": "https://bugcrowd.com/tesla", "targets": {"in_scope": [{"type": "website", "target": "*.tesla.com"}], "out_of_scope": []}},
+		{"id": "abc", "name": "Intigriti", "company_handle": "intigriti", "handle": "intigriti", "url": "https://www.intigriti.com/programs/intigriti/intigriti/detail",
+			"targets": {"in_scope": [{"type": "url", "endpoint": "app.intigriti.com", "description": "Main app"}], "out_of_scope": []}},
+		{"id": "swapcard-bug-bounty-program", "name": "Swapcard", "targets": {"in_scope": [{"target": "*.swapcard.com", "type": "web-application"}], "out_of_scope": [{"target": "status.swapcard.com", "type": "web-application"}]}},
+		{"name": "Unknown", "url": "https://example.com/program", "targets": {"in_scope": [], "out_of_scope": []}}
+	]`
+
+	dump, err = Load([]byte(others))
+	assert.NoError(t, err)
+	assert.Len(t, dump.Programs, 3, "Expected programs of unknown platforms to be skipped")
+	assert.Equal(t, "Bugcrowd", dump.Programs[0].ProgramDetails.Platform)
+	assert.Equal(t, "tesla", dump.Programs[0].ProgramDetails.ProgramName)
+	assert.Equal(t, "Intigriti", dump.Programs[1].ProgramDetails.Platform)
+	assert.Equal(t, "Main app", dump.Programs[1].Assets[0].Notes)
+	assert.Equal(t, "YesWeHack", dump.Programs[2].ProgramDetails.Platform)
+	assert.Equal(t, []string{"status.swapcard.com"}, dump.Programs[2].OutScope)
+
+	_, err = Load([]byte(`{"not": "a dump"}`))
+	assert.Error(t, err)
+}
+
+func TestFind(t *testing.T) {
+	dump := &Dump{Programs: []*common.Result{
+		{ProgramDetails: common.BugBountyProgram{Platform: "HackerOne", Business: "security", ProgramName: "security"}},
+		{ProgramDetails: common.BugBountyProgram{Platform: "Intigriti", Business: "intigriti", ProgramName: "intigriti"}},
+		{ProgramDetails: common.BugBountyProgram{Platform: "Bugcrowd", Business: "tesla", ProgramName: "tesla"}},
+	}}
+
+	tests := []struct {
+		query    string
+		platform string
+	}{
+		{"https://hackerone.com/security?type=team", "HackerOne"},
+		{"Security", "HackerOne"},
+		{"https://app.intigriti.com/programs/intigriti/intigriti/detail", "Intigriti"},
+		{"intigriti/intigriti", "Intigriti"},
+		{"https://bugcrowd.com/engagements/tesla", "Bugcrowd"},
+	}
+
+	for _, test := range tests {
+		result, err := dump.Find(test.query)
+		if assert.NoError(t, err, "Expected %q to be found", test.query) {
+			assert.Equal(t, test.platform, result.ProgramDetails.Platform, "Unexpected program for %q", test.query)
+		}
+	}
+
+	_, err := dump.Find("https://yeswehack.com/programs/security")
+	assert.True(t, errors.Is(err, common.ErrProgramNotFound))
+}
@@ -7,14 +7,18 @@ import (
 	"net/http"
 	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/root4loot/goutils/domainutil"
 	"github.com/root4loot/goutils/log"
 	"github.com/root4loot/goutils/sliceutil"
 	"github.com/root4loot/rescope/pkg/common"
+	"github.com/root4loot/rescope/pkg/freetext"
 )
 
+const platformName = "Bugcrowd"
+
 type Bugcrowd struct {
 	Result common.Result `json:"Result"`
 	Auth   string        // _bugcrowd_session=
@@ -31,10 +35,38 @@ type Target struct {
 }
 
 type Scope struct {
-	ID      string   `json:"id"`
-	Name    string   `json:"name"`
-	InScope bool     `json:"inScope"`
-	Targets []Target `json:"targets"`
+	ID              string                 `json:"id"`
+	Name            string                 `json:"name"`
+	InScope         bool                   `json:"inScope"`
+	RewardRangeData map[string]RewardRange `json:"rewardRangeData"` // by priority, "1" (P1) to "5" (P5)
+	Targets         []Target               `json:"targets"`
+}
+
+// RewardRange is the reward paid for findings of a priority
+type RewardRange struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+// reward returns whether the targets of the scope group earn rewards, nil if
+// the group lists no reward ranges, and the highest priority rewarded (e.g.
+// P1)
+func (s Scope) reward() (*bool, string) {
+	if s.RewardRangeData == nil {
+		return nil, ""
+	}
+
+	eligible := false
+	maxSeverity := ""
+	for priority := 1; priority <= 5; priority++ {
+		if reward, ok := s.RewardRangeData[strconv.Itoa(priority)]; ok && reward.Max > 0 {
+			if !eligible {
+				maxSeverity = "P" + strconv.Itoa(priority)
+			}
+			eligible = true
+		}
+	}
+	return &eligible, maxSeverity
 }
 
 type Data struct {
@@ -71,18 +103,21 @@ func (i *Bugcrowd) Run(programURL string, client *http.Client) (*common.Result,
 		return nil, err
 	}
 
+	defer resp.Body.Close()
+
 	respB, _ := io.ReadAll(resp.Body)
 
-	log.Debugf("Bugcrowd: Received response with status code %d and body: %s", resp.StatusCode, string(respB))
+	log.Debugf("Bugcrowd: Received response with status code %d (%d bytes)", resp.StatusCode, len(respB))
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("expected status code 200, got %d", resp.StatusCode)
+	if resp.StatusCode != http.StatusOK {
+		return nil, common.StatusError(platformName, resp.StatusCode, i.Auth != "")
 	}
 
-	defer resp.Body.Close()
-
 	re := regexp.MustCompile(`\/changelog\/(\w+-\w+-\w+-\w+-\w+)`)
 	UUID := re.FindString(string(respB))
+	if UUID == "" {
+		return nil, common.ParseError(platformName, fmt.Errorf("engagement changelog UUID not found"))
+	}
 	newURL := "https://bugcrowd.com/engagements/" + parsedURL.ProgramName + UUID + ".json"
 
 	req.URL, err = url.Parse(newURL)
@@ -97,14 +132,27 @@ func (i *Bugcrowd) Run(programURL string, client *http.Client) (*common.Result,
 	defer resp.Body.Close()
 	respB, _ = io.ReadAll(resp.Body)
 
+	if resp.StatusCode != http.StatusOK {
+		return nil, common.StatusError(platformName, resp.StatusCode, i.Auth != "")
+	}
+
 	var response JsonResponse
 	err = json.Unmarshal([]byte(respB), &response)
 	if err != nil {
-		return nil, err
+		return nil, common.ParseError(platformName, err)
 	}
 
 	for _, scope := range response.Data.Scopes {
 		for _, target := range scope.Targets {
+			if target.Description != "" {
+				source := "target description: " + target.Name
+				if scope.InScope {
+					i.Result.Derived = append(i.Result.Derived, freetext.Assets(freetext.ExtractExclusions(target.Description), source)...)
+				} else {
+					i.Result.Derived = append(i.Result.Derived, freetext.Assets(freetext.Extract(target.Description), source)...)
+				}
+			}
+
 			var targetEntry string
 			if domainutil.IsDomainName(target.Name) {
 				targetEntry = target.Name
@@ -121,6 +169,11 @@ func (i *Bugcrowd) Run(programURL string, client *http.Client) (*common.Result,
 			} else {
 				i.Result.OutScope = sliceutil.AppendUnique(i.Result.OutScope, targetEntry)
 			}
+			asset := common.Asset{Identifier: targetEntry, Category: target.Category, InScope: scope.InScope}
+			if scope.InScope {
+				asset.BountyEligible, asset.MaxSeverity = scope.reward()
+			}
+			i.Result.Assets = append(i.Result.Assets, asset)
 		}
 	}
 	return &i.Result, nil
@@ -154,7 +207,7 @@ func (b *Bugcrowd) ParseURL(rawURL string) (*common.BugBountyProgram, error) {
 
 	program := &common.BugBountyProgram{
 		InputURL:    parsedURL.String(),
-		Platform:    "Bugcrowd",
+		Platform:    platformName,
 		ProgramName: programName,
 		Business:    programName,
 		PolicyURL:   "https://" + parsedURL.Hostname() + "/engagements/" + programName,
@@ -163,6 +216,174 @@ func (b *Bugcrowd) ParseURL(rawURL string) (*common.BugBountyProgram, error) {
 	return program, nil
 }
 
+// engagementList is a page of the engagement directory
+type engagementList struct {
+	Engagements []struct {
+		Name          string `json:"name"`
+		BriefURL      string `json:"briefUrl"`
+		AccessStatus  string `json:"accessStatus"` // open for public engagements
+		RewardSummary struct {
+			MinReward string `json:"minReward"`
+			MaxReward string `json:"maxReward"`
+		} `json:"rewardSummary"`
+	} `json:"engagements"`
+	PaginationMeta struct {
+		TotalCount int `json:"totalCount"`
+	} `json:"paginationMeta"`
+}
+
+// directoryCategories maps the categories of the engagement directory to
+// program types
+var directoryCategories = []struct {
+	category    string
+	programType string
+}{
+	{"bug_bounty", common.ProgramTypeBounty},
+	{"vdp", common.ProgramTypeVDP},
+}
+
+// ListPrograms returns the engagements in the directory, including private
+// engagements the session is invited to
+func (b *Bugcrowd) ListPrograms(client *http.Client) ([]common.ProgramSummary, error) {
+	if client == nil {
+		client = &http.Client{}
+	}
+
+	var programs []common.ProgramSummary
+	for _, category := range directoryCategories {
+		listed := 0
+		for page := 1; ; page++ {
+			req, err := http.NewRequest("GET", fmt.Sprintf("https://bugcrowd.com/engagements.json?category=%s&page=%d", category.category, page), nil)
+			if err != nil {
+				return nil, err
+			}
+
+			req.Header.Set("Accept", "application/json")
+			if b.Auth != "" {
+				req.Header.Set("Cookie", `_bugcrowd_session="`+b.Auth+`"`)
+			}
+
+			resp, err := client.Do(req)
+			if err != nil {
+				return nil, err
+			}
+
+			respB, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				return nil, err
+			}
+
+			if resp.StatusCode != http.StatusOK {
+				return nil, common.StatusError(platformName, resp.StatusCode, b.Auth != "")
+			}
+
+			var list engagementList
+			if err := json.Unmarshal(respB, &list); err != nil {
+				return nil, common.ParseError(platformName, err)
+			}
+
+			programs = append(programs, list.summaries(category.programType)...)
+			listed += len(list.Engagements)
+
+			if len(list.Engagements) == 0 || listed >= list.PaginationMeta.TotalCount {
+				break
+			}
+		}
+	}
+	return programs, nil
+}
+
+// usernameRegex matches the username embedded in the pages of a signed in
+// researcher
+var usernameRegex = regexp.MustCompile(`"username":"([^"]+)"`)
+
+// CheckAuth verifies the session cookie by loading the researcher dashboard,
+// and returns the account's username if the page includes it. Bugcrowd
+// redirects to the sign in page once a session has ended, which is reported
+// as ErrAuthExpired.
+func (b *Bugcrowd) CheckAuth(client *http.Client) (string, error) {
+	if client == nil {
+		client = &http.Client{}
+	}
+
+	noRedirect := *client
+	noRedirect.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	req, err := http.NewRequest("GET", "https://bugcrowd.com/dashboard", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Cookie", `_bugcrowd_session="`+b.Auth+`"`)
+
+	resp, err := noRedirect.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	respB, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	log.Debugf("Bugcrowd: Received response with status code %d (%d bytes)", resp.StatusCode, len(respB))
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+	case resp.StatusCode >= 300 && resp.StatusCode < 400 && strings.Contains(resp.Header.Get("Location"), "sign_in"):
+		return "", &common.PlatformError{Platform: platformName, StatusCode: resp.StatusCode, Err: common.ErrAuthExpired}
+	default:
+		return "", common.StatusError(platformName, resp.StatusCode, true)
+	}
+
+	if m := usernameRegex.FindSubmatch(respB); m != nil {
+		return string(m[1]), nil
+	}
+	return "", nil
+}
+
+// summaries returns the engagements of the page as programs of the given type
+func (l *engagementList) summaries(programType string) []common.ProgramSummary {
+	var programs []common.ProgramSummary
+	for _, engagement := range l.Engagements {
+		minBounty, _ := parseReward(engagement.RewardSummary.MinReward)
+		maxBounty, currency := parseReward(engagement.RewardSummary.MaxReward)
+
+		programs = append(programs, common.ProgramSummary{
+			Platform:  platformName,
+			Name:      engagement.Name,
+			URL:       "https://bugcrowd.com" + engagement.BriefURL,
+			Type:      programType,
+			Status:    common.ProgramOpen,
+			Bounty:    maxBounty > 0,
+			MinBounty: minBounty,
+			MaxBounty: maxBounty,
+			Currency:  currency,
+			Private:   engagement.AccessStatus != "open",
+		})
+	}
+	return programs
+}
+
+// parseReward parses a reward such as "$15,000" into its amount and currency
+func parseReward(reward string) (float64, string) {
+	reward = strings.TrimSpace(reward)
+
+	var currency string
+	if strings.HasPrefix(reward, "$") {
+		currency = "USD"
+	}
+
+	amount, err := strconv.ParseFloat(strings.NewReplacer("$", "", ",", "").Replace(reward), 64)
+	if err != nil {
+		return 0, ""
+	}
+	return amount, currency
+}
+
 func (i *Bugcrowd) Serialize() (string, error) {
 	jsonData, err := json.Marshal(i.Result)
 	if err != nil {
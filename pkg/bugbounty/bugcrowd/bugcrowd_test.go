@@ -1,6 +1,7 @@
 package bugcrowd
 
 import (
+	"encoding/json"
 	"net/http"
 	"testing"
 
@@ -97,3 +98,53 @@ func TestParseURL(t *testing.T) {
 		}
 	}
 }
+
+func TestProgramSummaries(t *testing.T) {
+	var list engagementList
+	err := json.Unmarshal([]byte(`{"engagements": [
+		{"name": "Tesla", "briefUrl": "/engagements/tesla", "accessStatus": "open", "rewardSummary": {"minReward": "$100", "maxReward": "$15,000"}},
+		{"name": "Acme", "briefUrl": "/engagements/acme", "accessStatus": "invited", "rewardSummary": {}}
+	], "paginationMeta": {"totalCount": 2}}`), &list)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	programs := list.summaries(common.ProgramTypeBounty)
+	if len(programs) != 2 {
+		t.Fatalf("expected 2 programs, got %d", len(programs))
+	}
+
+	if programs[0].URL != "https://bugcrowd.com/engagements/tesla" || programs[0].MinBounty != 100 || programs[0].MaxBounty != 15000 || programs[0].Currency != "USD" {
+		t.Fatalf("unexpected program %+v", programs[0])
+	}
+
+	if programs[1].Bounty || !programs[1].Private {
+		t.Fatalf("expected a private program without bounty, got %+v", programs[1])
+	}
+}
+
+func TestScopeReward(t *testing.T) {
+	var response JsonResponse
+	err := json.Unmarshal([]byte(`{"data":{"scope":[
+		{"name":"Core","inScope":true,"rewardRangeData":{"1":{"min":2000,"max":5000},"2":{"min":500,"max":1000},"5":{"min":0,"max":0}}},
+		{"name":"Points only","inScope":true,"rewardRangeData":{"3":{"min":0,"max":0}}},
+		{"name":"Legacy","inScope":true}
+	]}}`), &response)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	eligible, maxSeverity := response.Data.Scopes[0].reward()
+	if eligible == nil || !*eligible || maxSeverity != "P1" {
+		t.Fatalf("expected a P1 reward, got %v %q", eligible, maxSeverity)
+	}
+
+	eligible, maxSeverity = response.Data.Scopes[1].reward()
+	if eligible == nil || *eligible || maxSeverity != "" {
+		t.Fatalf("expected no reward, got %v %q", eligible, maxSeverity)
+	}
+
+	if eligible, _ = response.Data.Scopes[2].reward(); eligible != nil {
+		t.Fatalf("expected unknown eligibility without reward ranges, got %v", *eligible)
+	}
+}
@@ -14,6 +14,8 @@ import (
 	"github.com/root4loot/rescope/pkg/common"
 )
 
+const platformName = "HackerOne"
+
 type HackerOne struct {
 	Result common.Result `json:"Result"`
 	Auth   string        // authorization bearer token
@@ -32,7 +34,7 @@ func (i *HackerOne) Run(programURL string, client *http.Client) (*common.Result,
 	i.Result.ProgramDetails = *parsedURL
 
 	var data = []byte(`{
-		"query":"query Team_assets($first_0:Int!) {query {id,...F0}} fragment F0 on Query {_teamAgUhl:team(handle:\"` + parsedURL.ProgramName + `\") {handle,_structured_scope_versions2ZWKHQ:structured_scope_versions(archived:false) {max_updated_at},_structured_scopeszxYtW:structured_scopes(first:$first_0,archived:false,eligible_for_submission:true) {edges {node {asset_type, asset_identifier}},pageInfo {hasNextPage,hasPreviousPage}},_structured_scopes3FF98f:structured_scopes(first:$first_0,archived:false,eligible_for_submission:false) {edges {node {asset_type,asset_identifier,},},},},}",
+		"query":"query Team_assets($first_0:Int!) {query {id,...F0}} fragment F0 on Query {_teamAgUhl:team(handle:\"` + parsedURL.ProgramName + `\") {handle,_structured_scope_versions2ZWKHQ:structured_scope_versions(archived:false) {max_updated_at},_structured_scopeszxYtW:structured_scopes(first:$first_0,archived:false,eligible_for_submission:true) {edges {node {asset_type, asset_identifier, eligible_for_bounty, max_severity}},pageInfo {hasNextPage,hasPreviousPage}},_structured_scopes3FF98f:structured_scopes(first:$first_0,archived:false,eligible_for_submission:false) {edges {node {asset_type,asset_identifier,eligible_for_bounty,max_severity},},},},}",
 		"variables":{
 		   "first_0":1337
 		}
@@ -55,10 +57,10 @@ func (i *HackerOne) Run(programURL string, client *http.Client) (*common.Result,
 		return nil, err
 	}
 
-	log.Debugf("HackerOne: Received response with status code %d and body: %s", resp.StatusCode, string(resB))
+	log.Debugf("HackerOne: Received response with status code %d (%d bytes)", resp.StatusCode, len(resB))
 
-	if resp.StatusCode != 200 {
-		return nil, err
+	if resp.StatusCode != http.StatusOK {
+		return nil, common.StatusError(platformName, resp.StatusCode, i.Auth != "")
 	}
 
 	hostsession, csrf, err := getSessionAndCSRF(*client)
@@ -89,24 +91,84 @@ func (i *HackerOne) Run(programURL string, client *http.Client) (*common.Result,
 		return nil, err
 	}
 
-	re := regexp.MustCompile(`\"edges":\[(.*?)\]`)
-	scopeSplit := re.FindAllString(string(resB), -1)
-	re = regexp.MustCompile(`asset_type":"(URL|CIDR|IP|IP-RANGE|RANGE)","asset_identifier":"(.*?)"`)
+	if resp.StatusCode != http.StatusOK {
+		return nil, common.StatusError(platformName, resp.StatusCode, i.Auth != "")
+	}
+
+	var assets teamAssets
+	if err := json.Unmarshal(resB, &assets); err != nil {
+		return nil, common.ParseError(platformName, err)
+	}
+
+	team := assets.Data.Query.Team
+	if team == nil {
+		if i.Auth == "" {
+			return nil, common.NewPlatformError(platformName, fmt.Errorf("%w (private programs require --auth-hackerone)", common.ErrProgramNotFound))
+		}
+		return nil, common.NewPlatformError(platformName, common.ErrProgramNotFound)
+	}
+	if team.InScope == nil || team.OutScope == nil {
+		return nil, common.ParseError(platformName, fmt.Errorf("missing structured scopes in GraphQL response"))
+	}
 
-	for _, match := range re.FindAllStringSubmatch(scopeSplit[0], -1) {
-		if strings.ToLower(match[2]) == "" {
-			continue
+	for _, edge := range team.InScope.Edges {
+		if asset, ok := edge.Node.asset(true); ok {
+			i.Result.InScope = append(i.Result.InScope, asset.Identifier)
+			i.Result.Assets = append(i.Result.Assets, asset)
 		}
-		i.Result.InScope = append(i.Result.InScope, match[2])
 	}
 
-	for _, match := range re.FindAllStringSubmatch(scopeSplit[1], -1) {
-		if strings.ToLower(match[2]) == "" {
-			continue
+	for _, edge := range team.OutScope.Edges {
+		if asset, ok := edge.Node.asset(false); ok {
+			i.Result.OutScope = append(i.Result.OutScope, asset.Identifier)
+			i.Result.Assets = append(i.Result.Assets, asset)
 		}
-		i.Result.OutScope = append(i.Result.OutScope, match[2])
 	}
-	return &i.Result, err
+	return &i.Result, nil
+}
+
+// teamAssets is the response to the structured scopes query of Run
+type teamAssets struct {
+	Data struct {
+		Query struct {
+			Team *struct {
+				InScope  *scopeConnection `json:"_structured_scopeszxYtW"`
+				OutScope *scopeConnection `json:"_structured_scopes3FF98f"`
+			} `json:"_teamAgUhl"`
+		} `json:"query"`
+	} `json:"data"`
+}
+
+type scopeConnection struct {
+	Edges []struct {
+		Node structuredScope `json:"node"`
+	} `json:"edges"`
+}
+
+// structuredScope is an asset of a program's structured scope
+type structuredScope struct {
+	AssetType         string `json:"asset_type"`
+	AssetIdentifier   string `json:"asset_identifier"`
+	EligibleForBounty *bool  `json:"eligible_for_bounty"`
+	MaxSeverity       string `json:"max_severity"`
+}
+
+// assetTypes are the structured scope types holding network targets
+var assetTypes = map[string]bool{"URL": true, "CIDR": true, "IP": true, "IP-RANGE": true, "RANGE": true}
+
+// asset returns the scope entry as an asset, and false if it isn't a network
+// target
+func (s structuredScope) asset(inScope bool) (common.Asset, bool) {
+	if !assetTypes[s.AssetType] || s.AssetIdentifier == "" {
+		return common.Asset{}, false
+	}
+	return common.Asset{
+		Identifier:     s.AssetIdentifier,
+		Category:       s.AssetType,
+		InScope:        inScope,
+		BountyEligible: s.EligibleForBounty,
+		MaxSeverity:    s.MaxSeverity,
+	}, true
 }
 
 func (r *HackerOne) ParseURL(rawURL string) (*common.BugBountyProgram, error) {
@@ -128,7 +190,7 @@ func (r *HackerOne) ParseURL(rawURL string) (*common.BugBountyProgram, error) {
 
 	urlStruct := &common.BugBountyProgram{
 		InputURL:    rawURL,
-		Platform:    "HackerOne",
+		Platform:    platformName,
 		ProgramName: program,
 		Business:    program,
 		PolicyURL:   "https://" + parsedURL.Host + "/" + program,
@@ -137,6 +199,195 @@ func (r *HackerOne) ParseURL(rawURL string) (*common.BugBountyProgram, error) {
 	return urlStruct, nil
 }
 
+// directoryQuery lists the programs open for submissions, a page at a time
+const directoryQuery = `query Directory($cursor: String) {
+	teams(first: 100, after: $cursor, where: {_and: [{_or: [{submission_state: {_eq: open}}, {submission_state: {_eq: paused}}]}, {_not: {external_program: {}}}]}) {
+		pageInfo { endCursor hasNextPage }
+		edges { node { handle name state submission_state offers_bounties currency bookmarked minimum_bounty_table_value maximum_bounty_table_value } }
+	}
+}`
+
+// directoryPage is a page of the directoryQuery response
+type directoryPage struct {
+	Data struct {
+		Teams struct {
+			PageInfo struct {
+				EndCursor   string `json:"endCursor"`
+				HasNextPage bool   `json:"hasNextPage"`
+			} `json:"pageInfo"`
+			Edges []struct {
+				Node struct {
+					Handle          string  `json:"handle"`
+					Name            string  `json:"name"`
+					State           string  `json:"state"` // soft_launched for private programs
+					SubmissionState string  `json:"submission_state"`
+					OffersBounties  bool    `json:"offers_bounties"`
+					Currency        string  `json:"currency"`
+					Bookmarked      bool    `json:"bookmarked"`
+					MinimumBounty   float64 `json:"minimum_bounty_table_value"`
+					MaximumBounty   float64 `json:"maximum_bounty_table_value"`
+				} `json:"node"`
+			} `json:"edges"`
+		} `json:"teams"`
+	} `json:"data"`
+}
+
+// ListPrograms returns the programs in the directory, including private
+// programs the token's account is invited to
+func (h *HackerOne) ListPrograms(client *http.Client) ([]common.ProgramSummary, error) {
+	if client == nil {
+		client = &http.Client{}
+	}
+
+	hostsession, csrf, err := getSessionAndCSRF(*client)
+	if err != nil {
+		return nil, err
+	}
+
+	var programs []common.ProgramSummary
+	var cursor *string
+	for {
+		data, err := json.Marshal(map[string]interface{}{
+			"query":     directoryQuery,
+			"variables": map[string]interface{}{"cursor": cursor},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		req, _ := http.NewRequest("POST", "https://hackerone.com/graphql", bytes.NewBuffer(data))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Cookie", hostsession)
+		req.Header.Set("X-Csrf-Token", csrf)
+		if h.Auth != "" {
+			req.Header.Set("X-Auth-Token", h.Auth)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		resB, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, common.StatusError(platformName, resp.StatusCode, h.Auth != "")
+		}
+
+		var page directoryPage
+		if err := json.Unmarshal(resB, &page); err != nil {
+			return nil, common.ParseError(platformName, err)
+		}
+
+		programs = append(programs, page.summaries()...)
+
+		pageInfo := page.Data.Teams.PageInfo
+		if !pageInfo.HasNextPage || pageInfo.EndCursor == "" {
+			return programs, nil
+		}
+		cursor = &pageInfo.EndCursor
+	}
+}
+
+// meQuery returns the account the token belongs to, or null if the token
+// isn't accepted
+const meQuery = `query { me { username } }`
+
+// CheckAuth returns the username of the token's account. HackerOne answers
+// requests with a rejected token as if they were unauthenticated, so the
+// token is only known to be accepted if the account is returned.
+func (h *HackerOne) CheckAuth(client *http.Client) (string, error) {
+	if client == nil {
+		client = &http.Client{}
+	}
+
+	hostsession, csrf, err := getSessionAndCSRF(*client)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(map[string]string{"query": meQuery})
+	if err != nil {
+		return "", err
+	}
+
+	req, _ := http.NewRequest("POST", "https://hackerone.com/graphql", bytes.NewBuffer(data))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Cookie", hostsession)
+	req.Header.Set("X-Csrf-Token", csrf)
+	req.Header.Set("X-Auth-Token", h.Auth)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	resB, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", common.TokenError(platformName, resp.StatusCode, h.Auth)
+	}
+
+	var me struct {
+		Data struct {
+			Me *struct {
+				Username string `json:"username"`
+			} `json:"me"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(resB, &me); err != nil {
+		return "", common.ParseError(platformName, err)
+	}
+
+	if me.Data.Me == nil {
+		return "", common.NewPlatformError(platformName, common.ErrAuthInvalid)
+	}
+	return me.Data.Me.Username, nil
+}
+
+// summaries returns the programs of the page
+func (p *directoryPage) summaries() []common.ProgramSummary {
+	var programs []common.ProgramSummary
+	for _, edge := range p.Data.Teams.Edges {
+		team := edge.Node
+		program := common.ProgramSummary{
+			Platform:  platformName,
+			Name:      team.Name,
+			URL:       "https://hackerone.com/" + team.Handle,
+			Type:      common.ProgramTypeVDP,
+			Status:    common.ProgramClosed,
+			Private:   team.State == "soft_launched",
+			Following: team.Bookmarked,
+		}
+
+		if team.OffersBounties {
+			program.Type = common.ProgramTypeBounty
+			program.Bounty = true
+			program.MinBounty = team.MinimumBounty
+			program.MaxBounty = team.MaximumBounty
+			program.Currency = strings.ToUpper(team.Currency)
+		}
+
+		switch team.SubmissionState {
+		case "open":
+			program.Status = common.ProgramOpen
+		case "paused":
+			program.Status = common.ProgramPaused
+		}
+
+		programs = append(programs, program)
+	}
+	return programs
+}
+
 func (h *HackerOne) Serialize() (string, error) {
 	jsonData, err := json.Marshal(h.Result)
 	if err != nil {
@@ -156,11 +407,17 @@ func getSessionAndCSRF(client http.Client) (hostsession, csrfToken string, err e
 		return hostsession, csrfToken, err
 	}
 
+	defer resp.Body.Close()
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return hostsession, csrfToken, err
 	}
 
+	if resp.StatusCode != http.StatusOK {
+		return hostsession, csrfToken, common.StatusError(platformName, resp.StatusCode, false)
+	}
+
 	cookies := resp.Header["Set-Cookie"]
 	for _, cookie := range cookies {
 		if strings.HasPrefix(cookie, "__Host-session") {
@@ -170,6 +427,9 @@ func getSessionAndCSRF(client http.Client) (hostsession, csrfToken string, err e
 
 	r := regexp.MustCompile(`<meta name="csrf-token" content="([\w+\/=]+)`)
 	m := r.FindStringSubmatch(string(body))
+	if len(m) < 2 {
+		return hostsession, csrfToken, common.ParseError(platformName, fmt.Errorf("csrf token not found"))
+	}
 	csrfToken = m[1]
 
 	return hostsession, csrfToken, err
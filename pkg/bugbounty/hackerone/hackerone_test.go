@@ -1,6 +1,7 @@
 package hackerone
 
 import (
+	"encoding/json"
 	"net/http"
 	"testing"
 
@@ -101,3 +102,37 @@ func TestParseURL(t *testing.T) {
 		}
 	}
 }
+
+func TestStructuredScopeAssets(t *testing.T) {
+	var assets teamAssets
+	err := json.Unmarshal([]byte(`{"data":{"query":{"id":"x","_teamAgUhl":{
+		"_structured_scopeszxYtW":{"edges":[
+			{"node":{"asset_type":"URL","asset_identifier":"api.example.com","eligible_for_bounty":true,"max_severity":"critical"}},
+			{"node":{"asset_type":"GOOGLE_PLAY_APP_ID","asset_identifier":"com.example.app","eligible_for_bounty":true,"max_severity":"high"}}
+		]},
+		"_structured_scopes3FF98f":{"edges":[
+			{"node":{"asset_type":"URL","asset_identifier":"blog.example.com","eligible_for_bounty":false,"max_severity":"none"}}
+		]}}}}}`), &assets)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	team := assets.Data.Query.Team
+	if team == nil || team.InScope == nil || len(team.InScope.Edges) != 2 || team.OutScope == nil || len(team.OutScope.Edges) != 1 {
+		t.Fatalf("expected 2 in-scope and 1 out-of-scope edges, got %+v", team)
+	}
+
+	asset, ok := team.InScope.Edges[0].Node.asset(true)
+	if !ok || asset.Identifier != "api.example.com" || asset.BountyEligible == nil || !*asset.BountyEligible || asset.MaxSeverity != "critical" {
+		t.Fatalf("expected an eligible critical asset, got %+v", asset)
+	}
+
+	if _, ok := team.InScope.Edges[1].Node.asset(true); ok {
+		t.Fatalf("expected app IDs to be skipped")
+	}
+
+	asset, _ = team.OutScope.Edges[0].Node.asset(false)
+	if asset.InScope || asset.BountyEligible == nil || *asset.BountyEligible {
+		t.Fatalf("expected an ineligible out-of-scope asset, got %+v", asset)
+	}
+}
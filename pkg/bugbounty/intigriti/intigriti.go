@@ -11,11 +11,15 @@ import (
 	"github.com/root4loot/goutils/log"
 	"github.com/root4loot/goutils/sliceutil"
 	"github.com/root4loot/rescope/pkg/common"
+	"github.com/root4loot/rescope/pkg/freetext"
 )
 
+const platformName = "Intigriti"
+
 type Intigriti struct {
 	Result common.Result `json:"Result"`
 	Auth   string        // authorization bearer token
+	Strict bool          // fail rather than fall back to public scope if private scope can't be fetched
 }
 
 func (i *Intigriti) Run(programURL string, client *http.Client) (*common.Result, error) {
@@ -39,7 +43,14 @@ func (i *Intigriti) Run(programURL string, client *http.Client) (*common.Result,
 			processPrivateScope(&i.Result, privateScopeDetails)
 			tryFetchPublicScope = false
 		} else {
-			log.Warn("Failed to fetch private scope data, will attempt public scope")
+			if err != nil && i.Strict {
+				return nil, err
+			}
+			if err != nil {
+				log.Warn("Failed to fetch private scope data, will attempt public scope", "error", err)
+			} else {
+				log.Warn("Program not found among private programs, will attempt public scope")
+			}
 		}
 	}
 
@@ -84,57 +95,119 @@ func (i *Intigriti) ParseURL(rawURL string) (*common.BugBountyProgram, error) {
 
 	return &common.BugBountyProgram{
 		InputURL:    u.String(),
-		Platform:    "Intigriti",
+		Platform:    platformName,
 		Business:    business,
 		ProgramName: program,
 		PolicyURL:   "https://" + u.Hostname() + "/" + business + "/" + program + "/detail",
 	}, nil
 }
 
-func (i *Intigriti) Serialize() (string, error) {
-	jsonData, err := json.Marshal(i.Result)
+// ListPrograms returns the programs the token gives access to, including
+// private invitations, or the public programs if no token is set
+func (i *Intigriti) ListPrograms(client *http.Client) ([]common.ProgramSummary, error) {
+	if client == nil {
+		client = &http.Client{}
+	}
+
+	if i.Auth == "" {
+		publicProgramList, err := fetchPublicProgramList(client)
+		if err != nil {
+			return nil, err
+		}
+		return publicProgramList.summaries(), nil
+	}
+
+	privateProgramList, err := fetchPrivateProgramList(i.Auth, client)
 	if err != nil {
-		return "", fmt.Errorf("failed to serialize Result: %w", err)
+		return nil, err
 	}
-	return string(jsonData), nil
+	return privateProgramList.summaries(), nil
 }
 
-func fetchPrivateProgramList(token string, client *http.Client) (*PrivateProgramList, error) {
-	endpoint := "https://api.intigriti.com/external/researcher/v1/programs?following=false"
+// CheckAuth verifies the token against the researcher API. The API doesn't
+// report the account a token belongs to, so no account name is returned.
+func (i *Intigriti) CheckAuth(client *http.Client) (string, error) {
+	if client == nil {
+		client = &http.Client{}
+	}
 
-	req, err := http.NewRequest("GET", endpoint, nil)
+	req, err := http.NewRequest("GET", "https://api.intigriti.com/external/researcher/v1/programs?limit=1", nil)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
 	req.Header.Add("Accept", "application/json")
-	req.Header.Add("Authorization", "Bearer "+token)
+	req.Header.Add("Authorization", "Bearer "+i.Auth)
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	defer resp.Body.Close()
+	resp.Body.Close()
 
-	respB, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	log.Debugf("Intigriti: Received response with status code %d and body: %s", resp.StatusCode, string(respB))
+	log.Debugf("Intigriti: Received response with status code %d", resp.StatusCode)
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("expected status code 200, got %d", resp.StatusCode)
+		return "", common.TokenError(platformName, resp.StatusCode, i.Auth)
 	}
+	return "", nil
+}
 
-	var privateProgramList PrivateProgramList
-
-	err = json.Unmarshal(respB, &privateProgramList)
+func (i *Intigriti) Serialize() (string, error) {
+	jsonData, err := json.Marshal(i.Result)
 	if err != nil {
-		return nil, err
+		return "", fmt.Errorf("failed to serialize Result: %w", err)
 	}
+	return string(jsonData), nil
+}
+
+// fetchPrivateProgramList returns every program the token gives access to,
+// fetched a page at a time
+func fetchPrivateProgramList(token string, client *http.Client) (*PrivateProgramList, error) {
+	var privateProgramList PrivateProgramList
+
+	for {
+		endpoint := fmt.Sprintf("https://api.intigriti.com/external/researcher/v1/programs?following=false&limit=%d&offset=%d", programPageSize, len(privateProgramList.Records))
+
+		req, err := http.NewRequest("GET", endpoint, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		req.Header.Add("Accept", "application/json")
+		req.Header.Add("Authorization", "Bearer "+token)
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		respB, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		log.Debugf("Intigriti: Received response with status code %d (%d bytes)", resp.StatusCode, len(respB))
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, common.StatusError(platformName, resp.StatusCode, true)
+		}
+
+		var page PrivateProgramList
 
-	return &privateProgramList, nil
+		err = json.Unmarshal(respB, &page)
+		if err != nil {
+			return nil, common.ParseError(platformName, err)
+		}
+
+		privateProgramList.MaxCount = page.MaxCount
+		privateProgramList.Records = append(privateProgramList.Records, page.Records...)
+
+		if len(page.Records) == 0 || len(privateProgramList.Records) >= page.MaxCount {
+			return &privateProgramList, nil
+		}
+	}
 }
 
 func fetchPrivateScope(url common.BugBountyProgram, token string, client http.Client) (*PrivateProgramDetail, error) {
@@ -160,9 +233,10 @@ func fetchPrivateScope(url common.BugBountyProgram, token string, client http.Cl
 			if err != nil {
 				return nil, err
 			}
+			defer resp.Body.Close()
 
 			if resp.StatusCode != http.StatusOK {
-				return nil, fmt.Errorf("expected status code 200, got %d", resp.StatusCode)
+				return nil, common.StatusError(platformName, resp.StatusCode, true)
 			}
 
 			respB, err := io.ReadAll(resp.Body)
@@ -174,7 +248,7 @@ func fetchPrivateScope(url common.BugBountyProgram, token string, client http.Cl
 
 			err = json.Unmarshal(respB, &privateProgramDetail)
 			if err != nil {
-				return nil, err
+				return nil, common.ParseError(platformName, err)
 			}
 
 			return &privateProgramDetail, nil
@@ -184,6 +258,39 @@ func fetchPrivateScope(url common.BugBountyProgram, token string, client http.Cl
 	return nil, nil
 }
 
+func fetchPublicProgramList(client *http.Client) (PublicProgramList, error) {
+	req, err := http.NewRequest("GET", "https://app.intigriti.com/api/core/public/programs", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, common.StatusError(platformName, resp.StatusCode, false)
+	}
+
+	respB, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var publicProgramList PublicProgramList
+
+	err = json.Unmarshal(respB, &publicProgramList)
+	if err != nil {
+		return nil, common.ParseError(platformName, err)
+	}
+
+	return publicProgramList, nil
+}
+
 func fetchPublicScope(program common.BugBountyProgram, client *http.Client) (*PublicProgramDetail, error) {
 	endpoint := fmt.Sprintf("https://app.intigriti.com/api/core/public/programs/%s/%s", program.Business, program.ProgramName)
 
@@ -198,9 +305,10 @@ func fetchPublicScope(program common.BugBountyProgram, client *http.Client) (*Pu
 	if err != nil {
 		return nil, err
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("expected status code 200, got %d", resp.StatusCode)
+		return nil, common.StatusError(platformName, resp.StatusCode, false)
 	}
 
 	respB, err := io.ReadAll(resp.Body)
@@ -212,12 +320,106 @@ func fetchPublicScope(program common.BugBountyProgram, client *http.Client) (*Pu
 
 	err = json.Unmarshal(respB, &publicProgramDetail)
 	if err != nil {
-		return nil, err
+		return nil, common.ParseError(platformName, err)
 	}
 
 	return &publicProgramDetail, nil
 }
 
+// Bounty tiers, program statuses and types as numbered by the public API
+const (
+	tierNoBounty   = 1
+	tierOutOfScope = 5
+
+	statusOpen      = 3
+	statusSuspended = 4
+
+	confidentialityPublic = 4
+
+	typeVDP = 2
+)
+
+// programPageSize is the number of programs requested per page of the
+// researcher API, its maximum
+const programPageSize = 500
+
+// summaries returns the programs of the list
+func (l *PrivateProgramList) summaries() []common.ProgramSummary {
+	var programs []common.ProgramSummary
+	for _, record := range l.Records {
+		program := common.ProgramSummary{
+			Platform:  platformName,
+			Name:      record.Name,
+			URL:       strings.Replace(record.WebLinks.Detail, "/researcher/programs/", "/programs/", 1),
+			Type:      common.ProgramTypeBounty,
+			Status:    common.ProgramClosed,
+			Bounty:    record.MaxBounty.Value > 0,
+			MinBounty: float64(record.MinBounty.Value),
+			MaxBounty: float64(record.MaxBounty.Value),
+			Currency:  record.MaxBounty.Currency,
+			Private:   !strings.EqualFold(record.ConfidentialityLevel.Value, "Public"),
+			Following: record.Following,
+		}
+
+		if strings.EqualFold(record.Type.Value, "Vdp") {
+			program.Type = common.ProgramTypeVDP
+		}
+
+		switch strings.ToLower(record.Status.Value) {
+		case "open":
+			program.Status = common.ProgramOpen
+		case "suspended":
+			program.Status = common.ProgramPaused
+		}
+
+		programs = append(programs, program)
+	}
+	return programs
+}
+
+// summaries returns the programs of the list
+func (l PublicProgramList) summaries() []common.ProgramSummary {
+	var programs []common.ProgramSummary
+	for _, record := range l {
+		program := common.ProgramSummary{
+			Platform:  platformName,
+			Name:      record.Name,
+			URL:       "https://app.intigriti.com/programs/" + record.CompanyHandle + "/" + record.Handle + "/detail",
+			Type:      common.ProgramTypeBounty,
+			Status:    common.ProgramClosed,
+			Bounty:    record.MaxBounty.Value > 0,
+			MinBounty: record.MinBounty.Value,
+			MaxBounty: record.MaxBounty.Value,
+			Currency:  record.MaxBounty.Currency,
+			Private:   record.ConfidentialityLevel != confidentialityPublic,
+		}
+
+		if record.Type == typeVDP {
+			program.Type = common.ProgramTypeVDP
+		}
+
+		switch record.Status {
+		case statusOpen:
+			program.Status = common.ProgramOpen
+		case statusSuspended:
+			program.Status = common.ProgramPaused
+		}
+
+		programs = append(programs, program)
+	}
+	return programs
+}
+
+var assetTypes = map[int]string{
+	1: "Url",
+	2: "Android",
+	3: "iOS",
+	4: "IpRange",
+	5: "Device",
+	6: "Other",
+	7: "Wildcard",
+}
+
 func processPrivateScope(Result *common.Result, scopeDetails *PrivateProgramDetail) {
 	for _, content := range scopeDetails.Domains.Content {
 		if content.Endpoint == "" {
@@ -225,6 +427,8 @@ func processPrivateScope(Result *common.Result, scopeDetails *PrivateProgramDeta
 		}
 		if content.Type.Value == "Url" {
 			Result.InScope = sliceutil.AppendUnique(Result.InScope, content.Endpoint)
+			eligible := content.Tier.Value != "No Bounty"
+			Result.Assets = append(Result.Assets, common.Asset{Identifier: content.Endpoint, Category: content.Type.Value, InScope: true, BountyEligible: &eligible})
 		}
 	}
 }
@@ -235,11 +439,21 @@ func processPublicScope(Result *common.Result, publicProgramDetail *PublicProgra
 			if content.Endpoint == "" {
 				continue
 			}
-			if content.BountyTierID != 5 {
+			asset := common.Asset{Identifier: content.Endpoint, Category: assetTypes[content.Type]}
+			if content.BountyTierID != tierOutOfScope {
 				Result.InScope = sliceutil.AppendUnique(Result.InScope, content.Endpoint)
+				eligible := content.BountyTierID != tierNoBounty
+				asset.InScope = true
+				asset.BountyEligible = &eligible
 			} else {
 				Result.OutScope = sliceutil.AppendUnique(Result.OutScope, content.Endpoint)
 			}
+			Result.Assets = append(Result.Assets, asset)
 		}
 	}
+
+	for _, outOfScope := range publicProgramDetail.OutOfScopes {
+		candidates := freetext.Extract(outOfScope.Content.Content)
+		Result.Derived = append(Result.Derived, freetext.Assets(candidates, "out-of-scope description")...)
+	}
 }
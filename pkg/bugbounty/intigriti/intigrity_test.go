@@ -1,6 +1,7 @@
 package intigriti
 
 import (
+	"encoding/json"
 	"net/http"
 	"testing"
 
@@ -110,3 +111,40 @@ func TestParseURL(t *testing.T) {
 		}
 	}
 }
+
+func TestProgramSummaries(t *testing.T) {
+	var list PrivateProgramList
+	err := json.Unmarshal([]byte(`{"maxCount": 1, "records": [{
+		"id": "1", "handle": "intigriti", "name": "Intigriti", "following": true,
+		"minBounty": {"value": 50, "currency": "EUR"}, "maxBounty": {"value": 5000, "currency": "EUR"},
+		"confidentialityLevel": {"id": 2, "value": "InviteOnly"}, "status": {"id": 4, "value": "Suspended"},
+		"type": {"id": 1, "value": "Bug bounty"},
+		"webLinks": {"detail": "https://app.intigriti.com/researcher/programs/intigriti/intigriti/detail"}
+	}]}`), &list)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := common.ProgramSummary{
+		Platform:  "Intigriti",
+		Name:      "Intigriti",
+		URL:       "https://app.intigriti.com/programs/intigriti/intigriti/detail",
+		Type:      common.ProgramTypeBounty,
+		Status:    common.ProgramPaused,
+		Bounty:    true,
+		MinBounty: 50,
+		MaxBounty: 5000,
+		Currency:  "EUR",
+		Private:   true,
+		Following: true,
+	}
+
+	programs := list.summaries()
+	if len(programs) != 1 || programs[0] != expected {
+		t.Fatalf("expected %+v, got %+v", expected, programs)
+	}
+
+	if _, err := platform.ParseURL(programs[0].URL); err != nil {
+		t.Fatalf("expected the program URL to be parseable, got %v", err)
+	}
+}
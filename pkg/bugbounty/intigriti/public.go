@@ -1,5 +1,24 @@
 package intigriti
 
+// PublicProgramList is the directory of public programs
+type PublicProgramList []struct {
+	ProgramID            string `json:"programId"`
+	CompanyHandle        string `json:"companyHandle"`
+	Handle               string `json:"handle"`
+	Name                 string `json:"name"`
+	Status               int    `json:"status"`
+	ConfidentialityLevel int    `json:"confidentialityLevel"`
+	Type                 int    `json:"type"`
+	MinBounty            struct {
+		Value    float64 `json:"value"`
+		Currency string  `json:"currency"`
+	} `json:"minBounty"`
+	MaxBounty struct {
+		Value    float64 `json:"value"`
+		Currency string  `json:"currency"`
+	} `json:"maxBounty"`
+}
+
 type PublicProgramDetail struct {
 	ProgramID            string `json:"programId"`
 	Status               int    `json:"status"`
@@ -0,0 +1,422 @@
+// Package securitytxt reads security.txt files (RFC 9116) as a scope source
+// for organizations outside the bug bounty platforms. Domains referenced in
+// the file are listed as candidate in-scope assets.
+package securitytxt
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/clearsign"
+	"github.com/root4loot/goutils/domainutil"
+	"github.com/root4loot/goutils/log"
+	"github.com/root4loot/goutils/sliceutil"
+	"github.com/root4loot/rescope/pkg/common"
+	"github.com/root4loot/rescope/pkg/normalize"
+)
+
+const platformName = "security.txt"
+
+// Signature states of a security.txt file
+const (
+	SignatureUnsigned   = "unsigned"
+	SignatureValid      = "valid"
+	SignatureInvalid    = "invalid"
+	SignatureUnverified = "unverified"
+)
+
+// candidateNote is attached to assets taken from a security.txt file, as the
+// file doesn't define scope
+const candidateNote = "candidate: referenced in security.txt, not confirmed as in scope"
+
+// thirdPartyDomains are root domains commonly referenced in security.txt
+// files that don't belong to the organization
+var thirdPartyDomains = map[string]bool{
+	"hackerone.com": true, "bugcrowd.com": true, "intigriti.com": true, "yeswehack.com": true,
+	"github.com": true, "gitlab.com": true, "keybase.io": true, "openpgp.org": true, "mit.edu": true,
+	"ubuntu.com": true, "gmail.com": true, "outlook.com": true, "hotmail.com": true, "proton.me": true,
+	"protonmail.com": true, "google.com": true, "forms.gle": true, "linkedin.com": true, "twitter.com": true,
+}
+
+type SecurityTxt struct {
+	Result common.Result `json:"Result"`
+	Key    string        // armored OpenPGP public key to verify signed files with
+}
+
+// IsURL reports whether rawURL points at a security.txt file
+func IsURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	return strings.HasSuffix(strings.ToLower(u.Path), "/security.txt")
+}
+
+// Detect reports whether data looks like a security.txt file
+func Detect(data []byte) bool {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("-----BEGIN PGP SIGNED MESSAGE-----")) {
+		return true
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		name, _, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if ok && (strings.EqualFold(name, "Contact") || strings.EqualFold(name, "Expires")) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *SecurityTxt) Run(programURL string, client *http.Client) (*common.Result, error) {
+	details, err := s.ParseURL(programURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if client == nil {
+		client = &http.Client{}
+	}
+
+	body, contentType, err := fetch(client, programURL)
+	if err != nil && strings.HasSuffix(programURL, "/.well-known/security.txt") {
+		// the legacy location is still served by some sites
+		legacyURL := strings.TrimSuffix(programURL, "/.well-known/security.txt") + "/security.txt"
+		log.Debug("security.txt not found at well-known location, trying legacy location", "url", legacyURL)
+		if legacyBody, legacyType, legacyErr := fetch(client, legacyURL); legacyErr == nil {
+			body, contentType, err = legacyBody, legacyType, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := []byte(s.Key)
+	if len(key) == 0 && bytes.Contains(body, []byte("-----BEGIN PGP SIGNED MESSAGE-----")) {
+		key = fetchKey(client, body)
+	}
+
+	result, err := Read(body, details, key)
+	if err != nil {
+		return nil, err
+	}
+
+	// a key taken from the file itself only shows the file is self-consistent
+	if file := result.ProgramDetails.SecurityTxt; len(s.Key) == 0 && file.Signature == SignatureValid {
+		file.Signature = SignatureUnverified
+		file.Warnings = append(file.Warnings, "signed with the key referenced in Encryption, which doesn't prove who published the file")
+	}
+
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType != "text/plain" {
+		result.ProgramDetails.SecurityTxt.Warnings = append(result.ProgramDetails.SecurityTxt.Warnings, fmt.Sprintf("served as %q instead of text/plain", contentType))
+	}
+	if canonical := result.ProgramDetails.SecurityTxt.Canonical; len(canonical) > 0 && !sliceutil.Contains(canonical, programURL) {
+		result.ProgramDetails.SecurityTxt.Warnings = append(result.ProgramDetails.SecurityTxt.Warnings, "fetched from a URL not listed in Canonical")
+	}
+
+	s.Result = *result
+	logWarnings(&s.Result)
+	return &s.Result, nil
+}
+
+func fetch(client *http.Client, rawURL string) (body []byte, contentType string, err error) {
+	req, err := http.NewRequest("GET", rawURL, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	body, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	log.Debugf("security.txt: Received response with status code %d (%d bytes)", resp.StatusCode, len(body))
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", common.StatusError(platformName, resp.StatusCode, false)
+	}
+
+	return body, resp.Header.Get("Content-Type"), nil
+}
+
+// fetchKey fetches the key from the first https Encryption URI of a signed
+// file. It returns nil if there's none or it can't be fetched.
+func fetchKey(client *http.Client, body []byte) []byte {
+	block, _ := clearsign.Decode(body)
+	if block == nil {
+		return nil
+	}
+
+	for _, uri := range fields(block.Plaintext)["encryption"] {
+		if !strings.HasPrefix(uri, "https://") {
+			continue
+		}
+		key, _, err := fetch(client, uri)
+		if err != nil {
+			log.Debug("Failed to fetch security.txt encryption key", "url", uri, "error", err)
+			continue
+		}
+		return key
+	}
+	return nil
+}
+
+// ParseURL returns the program details of a security.txt URL. The program is
+// named after the host serving the file.
+func (s *SecurityTxt) ParseURL(rawURL string) (*common.BugBountyProgram, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse URL: %w", err)
+	}
+
+	if !IsURL(rawURL) {
+		return nil, fmt.Errorf("not a security.txt URL: %s", rawURL)
+	}
+
+	return &common.BugBountyProgram{
+		InputURL:    rawURL,
+		Platform:    platformName,
+		ProgramName: u.Hostname(),
+		Business:    u.Hostname(),
+		PolicyURL:   rawURL,
+	}, nil
+}
+
+func (s *SecurityTxt) Serialize() (string, error) {
+	jsonData, err := json.Marshal(s.Result)
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize Result: %w", err)
+	}
+	return string(jsonData), nil
+}
+
+// ReadFile reads a local security.txt file. The program is named after the
+// first Canonical URL, if any.
+func ReadFile(data []byte, file string, key []byte) (*common.Result, error) {
+	details := &common.BugBountyProgram{InputURL: file, Platform: platformName}
+
+	result, err := Read(data, details, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if canonical := result.ProgramDetails.SecurityTxt.Canonical; len(canonical) > 0 {
+		if u, err := url.Parse(canonical[0]); err == nil {
+			result.ProgramDetails.ProgramName = u.Hostname()
+			result.ProgramDetails.Business = u.Hostname()
+		}
+	}
+
+	logWarnings(result)
+	return result, nil
+}
+
+// Read parses and validates a security.txt file into a result with the given
+// program details. Signed files are verified against key if given.
+func Read(data []byte, details *common.BugBountyProgram, key []byte) (*common.Result, error) {
+	file, err := Parse(data, key)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &common.Result{ProgramDetails: *details}
+	result.ProgramDetails.SecurityTxt = file
+	if len(file.Policy) > 0 {
+		result.ProgramDetails.PolicyURL = file.Policy[0]
+	}
+
+	add := func(host, field string) {
+		if host == "" || thirdPartyDomains[domainutil.GetRootDomain(host)] {
+			return
+		}
+		result.InScope = append(result.InScope, host)
+		result.Assets = append(result.Assets, common.Asset{Identifier: host, Category: field, InScope: true, Notes: candidateNote})
+	}
+
+	if u, err := url.Parse(details.InputURL); err == nil && u.Host != "" {
+		add(u.Hostname(), "URL")
+	}
+	for _, field := range uriFields(file) {
+		for _, value := range field.values {
+			add(uriHost(value), field.name)
+		}
+	}
+
+	normalize.Result(result)
+	return result, nil
+}
+
+// Parse reads the fields of a security.txt file and validates them against
+// RFC 9116. Deviations are listed as warnings rather than failing, as many
+// published files have some.
+func Parse(data []byte, key []byte) (*common.SecurityTxt, error) {
+	file := &common.SecurityTxt{Signature: SignatureUnsigned}
+	warn := func(format string, args ...interface{}) {
+		file.Warnings = append(file.Warnings, fmt.Sprintf(format, args...))
+	}
+
+	content := data
+	if block, _ := clearsign.Decode(data); block != nil {
+		content = block.Plaintext
+		if file.Signature = verify(block, key); file.Signature == SignatureInvalid {
+			warn("OpenPGP signature doesn't match the key")
+		}
+	} else if bytes.Contains(data, []byte("-----BEGIN PGP SIGNED MESSAGE-----")) {
+		file.Signature = SignatureInvalid
+		warn("malformed OpenPGP signature")
+	}
+
+	values := fields(content)
+	if len(values) == 0 {
+		return nil, common.ParseError(platformName, fmt.Errorf("no security.txt fields found"))
+	}
+
+	file.Contact = values["contact"]
+	file.Policy = values["policy"]
+	file.Canonical = values["canonical"]
+	file.Acknowledgments = values["acknowledgments"]
+	file.Encryption = values["encryption"]
+	file.Hiring = values["hiring"]
+
+	if len(file.Contact) == 0 {
+		warn("missing required Contact field")
+	}
+
+	switch expires := values["expires"]; len(expires) {
+	case 0:
+		warn("missing required Expires field")
+	default:
+		if len(expires) > 1 {
+			warn("Expires must appear only once")
+		}
+		file.Expires = expires[0]
+		date, err := time.Parse(time.RFC3339, file.Expires)
+		switch {
+		case err != nil:
+			warn("Expires is not an RFC 3339 date: %s", file.Expires)
+		case date.Before(time.Now()):
+			file.Expired = true
+			warn("expired on %s", date.Format(time.RFC3339))
+		case date.After(time.Now().AddDate(1, 0, 0)):
+			warn("Expires is more than a year ahead")
+		}
+	}
+
+	if languages := values["preferred-languages"]; len(languages) > 0 {
+		if len(languages) > 1 {
+			warn("Preferred-Languages must appear only once")
+		}
+		for _, language := range strings.Split(languages[0], ",") {
+			if language = strings.TrimSpace(language); language != "" {
+				file.PreferredLanguages = append(file.PreferredLanguages, language)
+			}
+		}
+	}
+
+	for _, field := range uriFields(file) {
+		for _, uri := range field.values {
+			if strings.HasPrefix(strings.ToLower(uri), "http://") {
+				warn("%s must use https: %s", field.name, uri)
+			}
+		}
+	}
+
+	return file, nil
+}
+
+type uriField struct {
+	name   string
+	values []string
+}
+
+// uriFields returns the fields of file holding URIs
+func uriFields(file *common.SecurityTxt) []uriField {
+	return []uriField{
+		{"Canonical", file.Canonical}, {"Contact", file.Contact}, {"Policy", file.Policy},
+		{"Acknowledgments", file.Acknowledgments}, {"Hiring", file.Hiring}, {"Encryption", file.Encryption},
+	}
+}
+
+// fields returns the values of each field by lowercased name. Comments and
+// unknown lines are skipped.
+func fields(data []byte) map[string][]string {
+	values := make(map[string][]string)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, value, ok := strings.Cut(line, ":")
+		if !ok || strings.ContainsAny(name, " \t") {
+			continue
+		}
+		name = strings.ToLower(name)
+		values[name] = append(values[name], strings.TrimSpace(value))
+	}
+
+	return values
+}
+
+// verify checks the signature of a clearsigned file against key
+func verify(block *clearsign.Block, key []byte) string {
+	if len(key) == 0 {
+		return SignatureUnverified
+	}
+
+	keyring, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(key))
+	if err != nil {
+		log.Debug("Failed to read security.txt key", "error", err)
+		return SignatureUnverified
+	}
+
+	if _, err := openpgp.CheckDetachedSignature(keyring, bytes.NewReader(block.Bytes), block.ArmoredSignature.Body, nil); err != nil {
+		return SignatureInvalid
+	}
+	return SignatureValid
+}
+
+// uriHost returns the host of a URI, or the domain of a mailto URI or plain
+// email address
+func uriHost(uri string) string {
+	if strings.HasPrefix(strings.ToLower(uri), "mailto:") {
+		uri = uri[len("mailto:"):]
+	}
+
+	if !strings.Contains(uri, "://") {
+		if address, err := mail.ParseAddress(uri); err == nil {
+			_, domain, _ := strings.Cut(address.Address, "@")
+			return domain
+		}
+		return ""
+	}
+
+	u, err := url.Parse(uri)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+func logWarnings(result *common.Result) {
+	for _, warning := range result.ProgramDetails.SecurityTxt.Warnings {
+		log.Warn("security.txt does not follow RFC 9116", "source", result.ProgramDetails.InputURL, "problem", warning)
+	}
+}
@@ -0,0 +1,152 @@
+package securitytxt
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/clearsign"
+	"github.com/stretchr/testify/assert"
+)
+
+func securityTxt(expires time.Time) string {
+	return "# Our security contacts\n" +
+		"Contact: mailto:security@example.com\n" +
+		"Contact: https://hackerone.com/example\n" +
+		"Expires: " + expires.UTC().Format(time.RFC3339) + "\n" +
+		"Policy: https://example.com/security-policy\n" +
+		"Acknowledgments: http://www.example.com/hall-of-fame\n" +
+		"Preferred-Languages: en, nl\n" +
+		"Canonical: https://example.com/.well-known/security.txt\n"
+}
+
+func TestParse(t *testing.T) {
+	file, err := Parse([]byte(securityTxt(time.Now().AddDate(0, 6, 0))), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"mailto:security@example.com", "https://hackerone.com/example"}, file.Contact)
+	assert.Equal(t, []string{"https://example.com/security-policy"}, file.Policy)
+	assert.Equal(t, []string{"en", "nl"}, file.PreferredLanguages)
+	assert.Equal(t, SignatureUnsigned, file.Signature)
+	assert.False(t, file.Expired)
+	assert.Equal(t, []string{"Acknowledgments must use https: http://www.example.com/hall-of-fame"}, file.Warnings)
+
+	file, err = Parse([]byte(securityTxt(time.Now().AddDate(0, -1, 0))), nil)
+	assert.NoError(t, err)
+	assert.True(t, file.Expired)
+
+	file, err = Parse([]byte("Policy: https://example.com/policy\nExpires: tomorrow\n"), nil)
+	assert.NoError(t, err)
+	assert.Contains(t, file.Warnings, "missing required Contact field")
+	assert.Contains(t, file.Warnings, "Expires is not an RFC 3339 date: tomorrow")
+
+	_, err = Parse([]byte("<html>not found</html>"), nil)
+	assert.Error(t, err)
+
+	assert.True(t, Detect([]byte(securityTxt(time.Now()))))
+	assert.False(t, Detect([]byte("example.com\n*.example.org")))
+	assert.True(t, IsURL("https://example.com/.well-known/security.txt"))
+	assert.False(t, IsURL("https://example.com/security"))
+}
+
+func TestRead(t *testing.T) {
+	result, err := ReadFile([]byte(securityTxt(time.Now().AddDate(0, 6, 0))), "security.txt", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "example.com", result.ProgramDetails.ProgramName)
+	assert.Equal(t, "https://example.com/security-policy", result.ProgramDetails.PolicyURL)
+	assert.Equal(t, []string{"example.com", "www.example.com"}, result.InScope, "Expected third party domains to be skipped")
+	assert.Equal(t, "Canonical", result.Assets[0].Category)
+	assert.Equal(t, candidateNote, result.Assets[0].Notes)
+}
+
+// sign clearsigns content with a new key and returns the signed file and the
+// armored public key
+func sign(t *testing.T, content string) (signed, key []byte) {
+	entity, err := openpgp.NewEntity("Example", "", "security@example.com", nil)
+	assert.NoError(t, err)
+
+	var signedBuf bytes.Buffer
+	plaintext, err := clearsign.Encode(&signedBuf, entity.PrivateKey, nil)
+	assert.NoError(t, err)
+	plaintext.Write([]byte(content))
+	plaintext.Close()
+
+	var keyBuf bytes.Buffer
+	armored, err := armor.Encode(&keyBuf, openpgp.PublicKeyType, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, entity.Serialize(armored))
+	armored.Close()
+
+	return signedBuf.Bytes(), keyBuf.Bytes()
+}
+
+func TestSignature(t *testing.T) {
+	signed, key := sign(t, securityTxt(time.Now().AddDate(0, 6, 0)))
+
+	file, err := Parse(signed, key)
+	assert.NoError(t, err)
+	assert.Equal(t, SignatureValid, file.Signature)
+	assert.Len(t, file.Contact, 2)
+
+	file, err = Parse(signed, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, SignatureUnverified, file.Signature)
+
+	tampered := bytes.Replace(signed, []byte("security@example.com"), []byte("security@evil.example"), 1)
+	file, err = Parse(tampered, key)
+	assert.NoError(t, err)
+	assert.Equal(t, SignatureInvalid, file.Signature)
+}
+
+func TestRun(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/security.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(securityTxt(time.Now().AddDate(0, 6, 0))))
+	}))
+	defer server.Close()
+
+	s := &SecurityTxt{}
+	result, err := s.Run(server.URL+"/.well-known/security.txt", server.Client())
+	assert.NoError(t, err, "Expected fallback to the legacy location")
+	assert.Equal(t, "security.txt", result.ProgramDetails.Platform)
+	assert.Equal(t, "127.0.0.1", result.InScope[0])
+	assert.Contains(t, result.ProgramDetails.SecurityTxt.Warnings, "fetched from a URL not listed in Canonical")
+	assert.False(t, strings.Contains(strings.Join(result.ProgramDetails.SecurityTxt.Warnings, ","), "text/plain"))
+}
+
+func TestRunSignedWithOwnKey(t *testing.T) {
+	var signed, key []byte
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/security.txt":
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			w.Write(signed)
+		case "/key.asc":
+			w.Write(key)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	signed, key = sign(t, securityTxt(time.Now().AddDate(0, 6, 0))+"Encryption: "+server.URL+"/key.asc\n")
+
+	s := &SecurityTxt{}
+	result, err := s.Run(server.URL+"/.well-known/security.txt", server.Client())
+	assert.NoError(t, err)
+	assert.Equal(t, SignatureUnverified, result.ProgramDetails.SecurityTxt.Signature, "Expected a key from the file itself not to verify it")
+	assert.Contains(t, result.ProgramDetails.SecurityTxt.Warnings, "signed with the key referenced in Encryption, which doesn't prove who published the file")
+
+	s = &SecurityTxt{Key: string(key)}
+	result, err = s.Run(server.URL+"/.well-known/security.txt", server.Client())
+	assert.NoError(t, err)
+	assert.Equal(t, SignatureValid, result.ProgramDetails.SecurityTxt.Signature)
+}
@@ -11,8 +11,12 @@ import (
 
 	"github.com/root4loot/goutils/log"
 	"github.com/root4loot/rescope/pkg/common"
+	"github.com/root4loot/rescope/pkg/freetext"
+	"github.com/root4loot/rescope/pkg/normalize"
 )
 
+const platformName = "YesWeHack"
+
 type YesWeHack struct {
 	Result common.Result `json:"Result"`
 	Auth   string        // authorization bearer token
@@ -39,11 +43,6 @@ func (i *YesWeHack) Run(programURL string, client *http.Client) (*common.Result,
 		client = &http.Client{}
 	}
 
-	req.URL, err = url.Parse(programURL)
-	if err != nil {
-		return nil, err
-	}
-
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
@@ -55,40 +54,77 @@ func (i *YesWeHack) Run(programURL string, client *http.Client) (*common.Result,
 		return nil, err
 	}
 
-	log.Debugf("YesWeHack: Received response with status code %d and body: %s", resp.StatusCode, string(body))
+	log.Debugf("YesWeHack: Received response with status code %d (%d bytes)", resp.StatusCode, len(body))
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("expected status code 200, got %d", resp.StatusCode)
+	if resp.StatusCode != http.StatusOK {
+		return nil, common.StatusError(platformName, resp.StatusCode, i.Auth != "")
 	}
 
-	inScopeRegex := regexp.MustCompile(`"scope":"([^"]*?(\*\.[a-zA-Z0-9.-]+|https?:\/\/[a-zA-Z0-9.-]+|[a-zA-Z0-9.-]+\.[a-zA-Z]{2,})(?:\\[^"]*)?)"`)
-	inScopeMatches := inScopeRegex.FindAllStringSubmatch(string(body), -1)
-
-	for _, match := range inScopeMatches {
-		if len(match) > 1 {
-			cleanedURL := strings.ReplaceAll(strings.TrimSpace(match[1]), `\`, ``)
-			if cleanedURL != "" {
-				i.Result.InScope = append(i.Result.InScope, cleanedURL)
-			}
-		}
+	var detail programDetail
+	if err := json.Unmarshal(body, &detail); err != nil {
+		return nil, common.ParseError(platformName, err)
 	}
 
-	outScopeRegex := regexp.MustCompile(`"out_of_scope":\[(.*?)\],`)
-	outScopeTextMatches := outScopeRegex.FindStringSubmatch(string(body))
+	i.Result.InScope = append(i.Result.InScope, detail.inScope()...)
+	outScope, derived := detail.outOfScope()
+	i.Result.OutScope = append(i.Result.OutScope, outScope...)
+	i.Result.Derived = append(i.Result.Derived, derived...)
 
-	if len(outScopeTextMatches) > 1 {
-		cleanedOutScopeText := strings.ReplaceAll(outScopeTextMatches[1], `\"`, `"`)
-		urlRegex := regexp.MustCompile(`(?:\*?\.)?[a-zA-Z0-9-]+(?:\.[a-zA-Z]{2,})(?:\.[a-zA-Z]{2,})?(?:$begin:math:text$[a-z|]+$end:math:text$)?`)
-		outScopeURLs := urlRegex.FindAllString(cleanedOutScopeText, -1)
+	return &i.Result, nil
+}
 
-		for _, url := range outScopeURLs {
-			if url != "" {
-				i.Result.OutScope = append(i.Result.OutScope, url)
-			}
+// programDetail holds the scope of a program as returned by the API
+type programDetail struct {
+	Scopes []struct {
+		Scope     string `json:"scope"`
+		ScopeType string `json:"scope_type"`
+	} `json:"scopes"`
+	OutOfScope []json.RawMessage `json:"out_of_scope"`
+}
+
+// scopeIdentifier matches the target of a scope entry that carries a
+// description along with it, e.g. "https://app.example.com (staging)"
+var scopeIdentifier = regexp.MustCompile(`\*\.[a-zA-Z0-9.-]+|https?://[a-zA-Z0-9.-]+|[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}`)
+
+// inScope returns the targets of the in-scope entries
+func (d *programDetail) inScope() []string {
+	var inScope []string
+	for _, scope := range d.Scopes {
+		target := strings.TrimSpace(scope.Scope)
+		if _, err := normalize.Parse(target); err != nil || strings.ContainsAny(target, " \t") {
+			target = scopeIdentifier.FindString(target)
+		}
+		if target == "" {
+			log.Debug("YesWeHack: Skipping scope entry without a target", "scope", scope.Scope, "type", scope.ScopeType)
+			continue
 		}
+		inScope = append(inScope, target)
 	}
+	return inScope
+}
 
-	return &i.Result, nil
+// outOfScope returns the out-of-scope entries, and the assets derived from
+// entries describing their exclusions in prose. Items that aren't strings are
+// skipped rather than failing the whole program.
+func (d *programDetail) outOfScope() ([]string, []common.Asset) {
+	var outScope []string
+	var derived []common.Asset
+	for _, raw := range d.OutOfScope {
+		var item string
+		if err := json.Unmarshal(raw, &item); err != nil {
+			log.Debug("YesWeHack: Skipping out-of-scope entry that isn't a string", "entry", string(raw))
+			continue
+		}
+
+		// items are usually plain identifiers, but some programs describe
+		// their exclusions in prose
+		if _, err := normalize.Parse(item); err == nil {
+			outScope = append(outScope, strings.TrimSpace(item))
+			continue
+		}
+		derived = append(derived, freetext.Assets(freetext.Extract(item), "out-of-scope description")...)
+	}
+	return outScope, derived
 }
 
 func (i *YesWeHack) ParseURL(rawURL string) (*common.BugBountyProgram, error) {
@@ -110,7 +146,7 @@ func (i *YesWeHack) ParseURL(rawURL string) (*common.BugBountyProgram, error) {
 
 	programStruct := &common.BugBountyProgram{
 		InputURL:    rawURL,
-		Platform:    "YesWeHack",
+		Platform:    platformName,
 		ProgramName: program,
 		Business:    program,
 		PolicyURL:   "https://" + parsedURL.Hostname() + "/programs/" + program,
@@ -119,6 +155,139 @@ func (i *YesWeHack) ParseURL(rawURL string) (*common.BugBountyProgram, error) {
 	return programStruct, nil
 }
 
+// programList is a page of the program directory
+type programList struct {
+	Items []struct {
+		Slug            string  `json:"slug"`
+		Title           string  `json:"title"`
+		Public          bool    `json:"public"`
+		Disabled        bool    `json:"disabled"`
+		Bounty          bool    `json:"bounty"`
+		BountyRewardMin float64 `json:"bounty_reward_min"`
+		BountyRewardMax float64 `json:"bounty_reward_max"`
+		VDP             bool    `json:"vdp"`
+	} `json:"items"`
+	Pagination struct {
+		Page    int `json:"page"`
+		NbPages int `json:"nb_pages"`
+	} `json:"pagination"`
+}
+
+// ListPrograms returns the public programs, and the private programs the
+// token gives access to
+func (y *YesWeHack) ListPrograms(client *http.Client) ([]common.ProgramSummary, error) {
+	if client == nil {
+		client = &http.Client{}
+	}
+
+	var programs []common.ProgramSummary
+	for page := 1; ; page++ {
+		req, err := http.NewRequest("GET", fmt.Sprintf("https://api.yeswehack.com/programs?page=%d&resultsPerPage=100", page), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if y.Auth != "" {
+			req.Header.Set("Authorization", "Bearer "+y.Auth)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, common.StatusError(platformName, resp.StatusCode, y.Auth != "")
+		}
+
+		var list programList
+		if err := json.Unmarshal(body, &list); err != nil {
+			return nil, common.ParseError(platformName, err)
+		}
+
+		programs = append(programs, list.summaries()...)
+
+		if page >= list.Pagination.NbPages {
+			return programs, nil
+		}
+	}
+}
+
+// CheckAuth returns the username of the token's account
+func (y *YesWeHack) CheckAuth(client *http.Client) (string, error) {
+	if client == nil {
+		client = &http.Client{}
+	}
+
+	req, err := http.NewRequest("GET", "https://api.yeswehack.com/user", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+y.Auth)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	log.Debugf("YesWeHack: Received response with status code %d (%d bytes)", resp.StatusCode, len(body))
+
+	if resp.StatusCode != http.StatusOK {
+		return "", common.TokenError(platformName, resp.StatusCode, y.Auth)
+	}
+
+	var user struct {
+		Username string `json:"username"`
+	}
+	if err := json.Unmarshal(body, &user); err != nil {
+		return "", common.ParseError(platformName, err)
+	}
+	return user.Username, nil
+}
+
+// summaries returns the programs of the page. Rewards are in euros.
+func (l *programList) summaries() []common.ProgramSummary {
+	var programs []common.ProgramSummary
+	for _, item := range l.Items {
+		program := common.ProgramSummary{
+			Platform:  platformName,
+			Name:      item.Title,
+			URL:       "https://yeswehack.com/programs/" + item.Slug,
+			Type:      common.ProgramTypeBounty,
+			Status:    common.ProgramOpen,
+			Bounty:    item.Bounty,
+			MinBounty: item.BountyRewardMin,
+			MaxBounty: item.BountyRewardMax,
+			Private:   !item.Public,
+		}
+
+		if item.VDP && !item.Bounty {
+			program.Type = common.ProgramTypeVDP
+		}
+		if item.Disabled {
+			program.Status = common.ProgramClosed
+		}
+		if item.Bounty {
+			program.Currency = "EUR"
+		}
+
+		programs = append(programs, program)
+	}
+	return programs
+}
+
 func (y *YesWeHack) Serialize() (string, error) {
 	jsonData, err := json.Marshal(y.Result)
 	if err != nil {
@@ -1,6 +1,7 @@
 package yeswehack
 
 import (
+	"encoding/json"
 	"net/http"
 	"testing"
 
@@ -97,3 +98,94 @@ func TestParseURL(t *testing.T) {
 		}
 	}
 }
+
+func TestProgramSummaries(t *testing.T) {
+	var list programList
+	err := json.Unmarshal([]byte(`{"items": [
+		{"slug": "swapcard-bug-bounty-program", "title": "Swapcard", "public": true, "disabled": false, "bounty": true, "bounty_reward_min": 50, "bounty_reward_max": 3000},
+		{"slug": "acme-vdp", "title": "Acme VDP", "public": false, "disabled": true, "bounty": false, "vdp": true}
+	], "pagination": {"page": 1, "nb_pages": 1}}`), &list)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	programs := list.summaries()
+	if len(programs) != 2 {
+		t.Fatalf("expected 2 programs, got %d", len(programs))
+	}
+
+	bounty := common.ProgramSummary{
+		Platform:  "YesWeHack",
+		Name:      "Swapcard",
+		URL:       "https://yeswehack.com/programs/swapcard-bug-bounty-program",
+		Type:      common.ProgramTypeBounty,
+		Status:    common.ProgramOpen,
+		Bounty:    true,
+		MinBounty: 50,
+		MaxBounty: 3000,
+		Currency:  "EUR",
+	}
+	if programs[0] != bounty {
+		t.Fatalf("expected %+v, got %+v", bounty, programs[0])
+	}
+
+	if programs[1].Type != common.ProgramTypeVDP || programs[1].Status != common.ProgramClosed || !programs[1].Private {
+		t.Fatalf("expected a closed private VDP, got %+v", programs[1])
+	}
+}
+
+func TestProgramDetail(t *testing.T) {
+	// trimmed response of https://api.yeswehack.com/programs/<slug>
+	body := []byte(`{
+		"id": 1042,
+		"title": "Example Bug Bounty Program",
+		"slug": "example-bug-bounty-program",
+		"public": true,
+		"bounty": true,
+		"rules": "## Rules\nPlease test *.example.com only with your own accounts.",
+		"scopes": [
+			{"scope": "*.example.com", "scope_type": "web-application", "scope_type_name": "Web application", "asset_value": "high"},
+			{"scope": "https://api.example.com/v2", "scope_type": "api", "scope_type_name": "API", "asset_value": "medium"},
+			{"scope": "https://staging.example.com (test accounts provided)", "scope_type": "web-application", "asset_value": "low"},
+			{"scope": "Any other asset owned by Example", "scope_type": "other", "asset_value": "low"}
+		],
+		"out_of_scope": [
+			"blog.example.com",
+			"See [our policy](https://example.com/policy)], excluding staging.example.com",
+			{"scope": "legacy.example.com"}
+		],
+		"reward_grid_default": {"bounty_low": 50, "bounty_critical": 5000},
+		"business_unit": {"name": "Example", "slug": "example"}
+	}`)
+
+	var detail programDetail
+	if err := json.Unmarshal(body, &detail); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	inScope := detail.inScope()
+	expected := []string{"*.example.com", "https://api.example.com/v2", "https://staging.example.com"}
+	if len(inScope) != len(expected) {
+		t.Fatalf("expected %v in scope, got %v", expected, inScope)
+	}
+	for i := range expected {
+		if inScope[i] != expected[i] {
+			t.Fatalf("expected %v in scope, got %v", expected, inScope)
+		}
+	}
+
+	outScope, derived := detail.outOfScope()
+	if len(outScope) != 1 || outScope[0] != "blog.example.com" {
+		t.Fatalf("expected [blog.example.com], got %v", outScope)
+	}
+
+	found := false
+	for _, asset := range derived {
+		if asset.Identifier == "staging.example.com" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected staging.example.com to be derived from the prose entry, got %+v", derived)
+	}
+}
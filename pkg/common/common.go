@@ -1,5 +1,12 @@
 package common
 
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
 type BugBountyProgram struct {
 	InputURL    string `json:"input_url"`
 	Platform    string `json:"platform"`
@@ -7,11 +14,112 @@ type BugBountyProgram struct {
 	ProgramName string `json:"program"`
 	PolicyURL   string `json:"policy_url"`
 	FetchedAt   string `json:"fetched_at"`
+
+	SecurityTxt *SecurityTxt `json:"security_txt,omitempty"` // set for programs read from a security.txt file
 }
 
 type Result struct {
 	ProgramDetails BugBountyProgram `json:"program"`
 	InScope        []string         `json:"in_scope"`
 	OutScope       []string         `json:"out_scope"`
+	Assets         []Asset          `json:"assets,omitempty"` // metadata for the InScope and OutScope entries
+	Derived        []Asset          `json:"derived,omitempty"`
+	Unparsed       []string         `json:"unparsed,omitempty"`
 	FetchedAt      string           `json:"fetched_at"`
 }
+
+// Asset holds a scope entry along with its metadata
+type Asset struct {
+	Identifier     string  `json:"identifier"`
+	Type           string  `json:"type,omitempty"`
+	Category       string  `json:"category,omitempty"` // asset type as reported by the platform
+	InScope        bool    `json:"in_scope"`
+	BountyEligible *bool   `json:"bounty_eligible,omitempty"` // nil if the platform doesn't say
+	MaxSeverity    string  `json:"max_severity,omitempty"`
+	Notes          string  `json:"notes,omitempty"`
+	Derived        bool    `json:"derived,omitempty"`    // extracted from free text rather than listed as an asset
+	Confidence     float64 `json:"confidence,omitempty"` // 0-1, set for derived assets
+	Source         string  `json:"source,omitempty"`     // where a derived asset was found
+}
+
+// SecurityTxt holds the fields of a security.txt file (RFC 9116)
+type SecurityTxt struct {
+	Contact            []string `json:"contact"`
+	Policy             []string `json:"policy,omitempty"`
+	Expires            string   `json:"expires"`
+	Expired            bool     `json:"expired"`
+	Canonical          []string `json:"canonical,omitempty"`
+	Acknowledgments    []string `json:"acknowledgments,omitempty"`
+	PreferredLanguages []string `json:"preferred_languages,omitempty"`
+	Encryption         []string `json:"encryption,omitempty"`
+	Hiring             []string `json:"hiring,omitempty"`
+	Signature          string   `json:"signature"`          // unsigned, valid, invalid or unverified (no key to check against)
+	Warnings           []string `json:"warnings,omitempty"` // deviations from RFC 9116
+}
+
+// ProgramSummary describes a program as listed in a platform's program
+// directory
+type ProgramSummary struct {
+	Platform  string  `json:"platform"`
+	Name      string  `json:"name"`
+	URL       string  `json:"url"`
+	Type      string  `json:"type"`   // bug bounty or vdp
+	Status    string  `json:"status"` // open, paused or closed
+	Bounty    bool    `json:"bounty"`
+	MinBounty float64 `json:"min_bounty,omitempty"`
+	MaxBounty float64 `json:"max_bounty,omitempty"`
+	Currency  string  `json:"currency,omitempty"`
+	Private   bool    `json:"private"`
+	Following bool    `json:"following"` // followed or bookmarked, where the platform supports it
+}
+
+// Program types and statuses used in ProgramSummary
+const (
+	ProgramTypeBounty = "bug bounty"
+	ProgramTypeVDP    = "vdp"
+
+	ProgramOpen   = "open"
+	ProgramPaused = "paused"
+	ProgramClosed = "closed"
+)
+
+// AuthStatus describes whether a platform accepts a credential, and the
+// account it belongs to
+type AuthStatus struct {
+	Platform  string `json:"platform"`
+	Profile   string `json:"profile,omitempty"`
+	Status    string `json:"status"`            // valid, invalid, expired or error
+	Account   string `json:"account,omitempty"` // as reported by the platform, or the configured account name
+	ExpiresAt string `json:"expires_at,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Credential statuses used in AuthStatus
+const (
+	AuthValid   = "valid"
+	AuthInvalid = "invalid"
+	AuthExpired = "expired"
+	AuthError   = "error" // the credential couldn't be checked, e.g. the platform was unreachable
+)
+
+// TokenExpiry returns the expiry time of a JWT, and false for tokens that
+// aren't JWTs or don't expire. The signature isn't verified.
+func TokenExpiry(token string) (time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(claims.Exp, 0), true
+}
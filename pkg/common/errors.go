@@ -0,0 +1,87 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Sentinel errors returned (wrapped) by the platform adapters. Use errors.Is
+// to tell them apart, e.g. to retry on ErrRateLimited but not on ErrAuthInvalid.
+var (
+	ErrProgramNotFound = errors.New("program not found")
+	ErrAuthRequired    = errors.New("authentication required")
+	ErrAuthInvalid     = errors.New("invalid or expired credentials")
+	ErrAuthExpired     = fmt.Errorf("expired credentials: %w", ErrAuthInvalid) // also matches ErrAuthInvalid
+	ErrRateLimited     = errors.New("rate limited")
+	ErrParse           = errors.New("unexpected response format")
+	ErrEmptyScope      = errors.New("empty scope")
+)
+
+// PlatformError describes a failure while fetching a program from a platform
+type PlatformError struct {
+	Platform   string
+	StatusCode int
+	Err        error
+}
+
+func (e *PlatformError) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("%s: %v (status code %d)", e.Platform, e.Err, e.StatusCode)
+	}
+	return fmt.Sprintf("%s: %v", e.Platform, e.Err)
+}
+
+func (e *PlatformError) Unwrap() error {
+	return e.Err
+}
+
+// NewPlatformError wraps err with the platform it originated from
+func NewPlatformError(platform string, err error) error {
+	return &PlatformError{Platform: platform, Err: err}
+}
+
+// ParseError wraps err as an ErrParse for the given platform
+func ParseError(platform string, err error) error {
+	if err == nil {
+		return &PlatformError{Platform: platform, Err: ErrParse}
+	}
+	return &PlatformError{Platform: platform, Err: fmt.Errorf("%w: %w", ErrParse, err)}
+}
+
+// StatusError maps a non-200 HTTP status code to one of the sentinel errors.
+// authenticated tells whether credentials were sent with the request, which
+// decides between ErrAuthRequired and ErrAuthInvalid.
+func StatusError(platform string, statusCode int, authenticated bool) error {
+	var err error
+
+	switch statusCode {
+	case http.StatusNotFound:
+		err = ErrProgramNotFound
+	case http.StatusUnauthorized, http.StatusForbidden:
+		if authenticated {
+			err = ErrAuthInvalid
+		} else {
+			err = ErrAuthRequired
+		}
+	case http.StatusTooManyRequests:
+		err = ErrRateLimited
+	default:
+		err = fmt.Errorf("expected status code 200, got %d", statusCode)
+	}
+
+	return &PlatformError{Platform: platform, StatusCode: statusCode, Err: err}
+}
+
+// TokenError maps the status code of a request made with token to ErrAuthExpired
+// if the platform rejected a JWT past its expiry time, and to the error
+// returned by StatusError otherwise
+func TokenError(platform string, statusCode int, token string) error {
+	if statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden {
+		if expiry, ok := TokenExpiry(token); ok && time.Now().After(expiry) {
+			return &PlatformError{Platform: platform, StatusCode: statusCode, Err: ErrAuthExpired}
+		}
+	}
+	return StatusError(platform, statusCode, token != "")
+}
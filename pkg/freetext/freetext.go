@@ -0,0 +1,178 @@
+// Package freetext extracts scope identifiers from program descriptions and
+// other markdown/HTML prose.
+package freetext
+
+import (
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/root4loot/rescope/pkg/common"
+	"github.com/root4loot/rescope/pkg/normalize"
+)
+
+// Candidate is an identifier found in text along with how confident the
+// extractor is that it was meant as a scope entry
+type Candidate struct {
+	Value      string
+	Kind       normalize.Kind
+	Confidence float64
+}
+
+var (
+	anchorRegex   = regexp.MustCompile(`(?i)<a\s[^>]*href=["']([^"']+)["'][^>]*>`)
+	breakRegex    = regexp.MustCompile(`(?i)<br\s*/?>|</p>|</li>|</div>`)
+	tagRegex      = regexp.MustCompile(`(?s)<[^>]*>`)
+	mdLinkRegex   = regexp.MustCompile(`\[([^\]]*)\]\(([^)\s]+)\)`)
+	codeRegex     = regexp.MustCompile("`([^`\n]+)`")
+	urlRegex      = regexp.MustCompile(`(?i)\bhttps?://[^\s<>"'()\[\]{}|\\^` + "`" + `]+`)
+	ipRangeRegex  = regexp.MustCompile(`\b\d{1,3}(?:\.\d{1,3}){3}\s*-\s*\d{1,3}(?:\.\d{1,3}){3}\b`)
+	cidrRegex     = regexp.MustCompile(`\b\d{1,3}(?:\.\d{1,3}){3}/\d{1,2}\b`)
+	ipRegex       = regexp.MustCompile(`\b\d{1,3}(?:\.\d{1,3}){3}\b`)
+	hostRegex     = regexp.MustCompile(`(?i)(?:\*\.)?(?:[a-z0-9*](?:[a-z0-9*-]*[a-z0-9*])?\.)+[a-z]{2,63}\b(?::\d{1,5})?`)
+	sentenceRegex = regexp.MustCompile(`[.!?](?:\s+|$)|\n`)
+	exclusionHint = regexp.MustCompile(`(?i)out[- ]of[- ]scope|not in scope|exclud|do not test|don't test|must not|not eligible|is not allowed`)
+)
+
+// fileExtensions are TLD-like suffixes that are far more likely to be file
+// names than hosts when found in prose
+var fileExtensions = map[string]bool{
+	"php": true, "js": true, "html": true, "htm": true, "md": true, "txt": true,
+	"json": true, "xml": true, "png": true, "jpg": true, "jpeg": true, "gif": true,
+	"svg": true, "pdf": true, "zip": true, "css": true, "asp": true, "aspx": true,
+	"jsp": true, "yaml": true, "yml": true, "exe": true, "apk": true, "ipa": true,
+}
+
+// Extract returns all identifiers found in text. Identifiers inside links or
+// code spans, and URLs, wildcards, IPs and CIDRs get a higher confidence than
+// bare domain names mentioned in prose.
+func Extract(text string) []Candidate {
+	text = clean(text)
+	found := make(map[string]Candidate)
+
+	add := func(raw string, confidence float64) {
+		entries, _ := normalize.Normalize([]string{raw})
+		for _, value := range entries {
+			entry, err := normalize.Parse(value)
+			if err != nil {
+				continue
+			}
+			if entry.Kind == normalize.KindURL && entry.Path == "" {
+				// links to pages are often references rather than scope,
+				// bare origins rarely are
+				confidence += 0.2
+			}
+			if existing, ok := found[value]; !ok || existing.Confidence < confidence {
+				found[value] = Candidate{Value: value, Kind: entry.Kind, Confidence: min(confidence, 1)}
+			}
+		}
+	}
+
+	emphasized := make(map[string]bool)
+	for _, match := range codeRegex.FindAllStringSubmatch(text, -1) {
+		emphasized[strings.TrimSpace(match[1])] = true
+	}
+	for _, match := range mdLinkRegex.FindAllStringSubmatch(text, -1) {
+		emphasized[strings.TrimSpace(match[1])] = true
+	}
+	text = mdLinkRegex.ReplaceAllString(text, "$1 $2")
+	text = strings.ReplaceAll(text, "`", " ")
+
+	for _, match := range urlRegex.FindAllString(text, -1) {
+		add(strings.TrimRight(match, ".,;:!?*"), 0.6)
+	}
+	text = urlRegex.ReplaceAllString(text, " ")
+
+	for _, match := range ipRangeRegex.FindAllString(text, -1) {
+		add(match, 0.8)
+	}
+	text = ipRangeRegex.ReplaceAllString(text, " ")
+
+	for _, match := range cidrRegex.FindAllString(text, -1) {
+		add(match, 0.9)
+	}
+	text = cidrRegex.ReplaceAllString(text, " ")
+
+	for _, match := range ipRegex.FindAllString(text, -1) {
+		add(match, 0.7)
+	}
+	text = ipRegex.ReplaceAllString(text, " ")
+
+	for _, loc := range hostRegex.FindAllStringIndex(text, -1) {
+		match := text[loc[0]:loc[1]]
+
+		// skip the domain part of email addresses
+		if loc[0] > 0 && text[loc[0]-1] == '@' {
+			continue
+		}
+
+		tld := match[strings.LastIndex(match, ".")+1:]
+		if i := strings.Index(tld, ":"); i >= 0 {
+			tld = tld[:i]
+		}
+		if fileExtensions[strings.ToLower(tld)] {
+			continue
+		}
+
+		confidence := 0.5
+		switch {
+		case strings.HasPrefix(match, "*."):
+			confidence = 0.9
+		case emphasized[match]:
+			confidence = 0.8
+		}
+		add(match, confidence)
+	}
+
+	candidates := make([]Candidate, 0, len(found))
+	for _, candidate := range found {
+		candidates = append(candidates, candidate)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Confidence != candidates[j].Confidence {
+			return candidates[i].Confidence > candidates[j].Confidence
+		}
+		return candidates[i].Value < candidates[j].Value
+	})
+
+	return candidates
+}
+
+// ExtractExclusions is like Extract but only considers sentences that talk
+// about something being out of scope. Use it on text that mixes in-scope and
+// out-of-scope information, such as target descriptions.
+func ExtractExclusions(text string) []Candidate {
+	var relevant []string
+	for _, sentence := range sentenceRegex.Split(clean(text), -1) {
+		if exclusionHint.MatchString(sentence) {
+			relevant = append(relevant, sentence)
+		}
+	}
+	return Extract(strings.Join(relevant, "\n"))
+}
+
+// Assets converts candidates to derived out-of-scope assets
+func Assets(candidates []Candidate, source string) []common.Asset {
+	var assets []common.Asset
+	for _, candidate := range candidates {
+		assets = append(assets, common.Asset{
+			Identifier: candidate.Value,
+			Type:       string(candidate.Kind),
+			Derived:    true,
+			Confidence: candidate.Confidence,
+			Source:     source,
+		})
+	}
+	return assets
+}
+
+// clean turns HTML into plain text, keeping link targets
+func clean(text string) string {
+	text = anchorRegex.ReplaceAllString(text, " $1 ")
+	text = breakRegex.ReplaceAllString(text, "\n")
+	text = tagRegex.ReplaceAllString(text, " ")
+	text = html.UnescapeString(text)
+	text = strings.NewReplacer("**", " ", "__", " ").Replace(text)
+	return text
+}
@@ -0,0 +1,40 @@
+package freetext
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtract(t *testing.T) {
+	text := `## Out of scope
+- ` + "`staging.example.com`" + ` and anything under **\*.internal.example.com**
+- Our office network 10.20.0.0/16
+- <a href="https://legacy.example.com/">the legacy portal</a>
+- Reports about config.php or mail sent to security@example.com
+- Example.org is owned by a third party.`
+
+	candidates := Extract(text)
+
+	values := make(map[string]float64)
+	for _, candidate := range candidates {
+		values[candidate.Value] = candidate.Confidence
+	}
+
+	assert.Equal(t, 0.8, values["staging.example.com"])
+	assert.Equal(t, 0.9, values["*.internal.example.com"])
+	assert.Equal(t, 0.9, values["10.20.0.0/16"])
+	assert.Equal(t, 0.8, values["https://legacy.example.com"])
+	assert.Equal(t, 0.5, values["example.org"])
+	assert.NotContains(t, values, "config.php")
+	assert.NotContains(t, values, "example.com")
+}
+
+func TestExtractExclusions(t *testing.T) {
+	text := "Main site www.example.com. Note that blog.example.com is out of scope.\nPayments are handled by pay.example.net"
+
+	candidates := ExtractExclusions(text)
+
+	assert.Len(t, candidates, 1)
+	assert.Equal(t, "blog.example.com", candidates[0].Value)
+}
@@ -0,0 +1,153 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/root4loot/goutils/iputil"
+)
+
+// burpScope holds the scope rules of a Burp project options file in either
+// advanced or simple mode
+type burpScope struct {
+	Target struct {
+		Scope struct {
+			AdvancedMode bool       `json:"advancedMode"`
+			Include      []burpRule `json:"include"`
+			Exclude      []burpRule `json:"exclude"`
+		} `json:"scope"`
+	} `json:"target"`
+}
+
+type burpRule struct {
+	Enabled           bool   `json:"enabled"`
+	Protocol          string `json:"protocol"`
+	Host              string `json:"host"`
+	Port              string `json:"port"`
+	File              string `json:"file"`
+	Prefix            string `json:"prefix"`
+	IncludeSubdomains bool   `json:"include_subdomains"`
+}
+
+// isBurp reports whether data is a JSON object with Burp's target.scope
+// include or exclude rules
+func isBurp(data []byte) bool {
+	var config struct {
+		Target struct {
+			Scope struct {
+				Include json.RawMessage `json:"include"`
+				Exclude json.RawMessage `json:"exclude"`
+			} `json:"scope"`
+		} `json:"target"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return false
+	}
+	return config.Target.Scope.Include != nil || config.Target.Scope.Exclude != nil
+}
+
+// Burp reads the scope of a Burp project options or scope file. Disabled
+// rules are skipped.
+func Burp(data []byte) (*Scope, error) {
+	var config burpScope
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse Burp scope: %w", err)
+	}
+
+	scope := &Scope{}
+	for _, rule := range config.Target.Scope.Include {
+		if rule.Enabled {
+			scope.add(&scope.Includes, burpEntry(rule), burpRuleString(rule))
+		}
+	}
+	for _, rule := range config.Target.Scope.Exclude {
+		if rule.Enabled {
+			scope.add(&scope.Excludes, burpEntry(rule), burpRuleString(rule))
+		}
+	}
+
+	return scope, nil
+}
+
+// burpEntry converts a rule to a scope entry, or returns an empty string if
+// it can't be expressed as one
+func burpEntry(rule burpRule) string {
+	if rule.Prefix != "" {
+		return burpPrefixEntry(rule)
+	}
+
+	var host string
+	if iputil.IsIP(rule.Host) || iputil.IsCIDR(rule.Host) || iputil.IsIPRange(rule.Host) {
+		host = rule.Host
+	} else {
+		glob, err := unregex(rule.Host)
+		if err != nil || glob == "" {
+			return ""
+		}
+		host = globHost(glob)
+	}
+
+	port, err := unregex(rule.Port)
+	if err != nil || strings.Contains(port, "*") {
+		return ""
+	}
+
+	path, err := unregex(rule.File)
+	if err != nil {
+		return ""
+	}
+	path = strings.TrimRight(path, "*")
+	if path != "" && !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	if strings.Contains(host, "/") {
+		// CIDRs can't be combined with a port or path
+		if port != "" || path != "" {
+			return ""
+		}
+		return host
+	}
+
+	entry := host
+	if port != "" {
+		entry += ":" + port
+	}
+	entry += path
+
+	switch strings.ToLower(rule.Protocol) {
+	case "http", "https":
+		entry = strings.ToLower(rule.Protocol) + "://" + entry
+	}
+
+	return entry
+}
+
+func burpPrefixEntry(rule burpRule) string {
+	prefix := rule.Prefix
+	if !strings.Contains(prefix, "://") {
+		prefix = "placeholder://" + prefix
+	}
+
+	u, err := url.Parse(prefix)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+
+	if rule.IncludeSubdomains {
+		// the parent domain is matched as well, which can't be expressed
+		// in a single entry; the wildcard covers the subdomains
+		u.Host = "*." + u.Host
+	}
+
+	return strings.TrimPrefix(u.String(), "placeholder://")
+}
+
+func burpRuleString(rule burpRule) string {
+	if rule.Prefix != "" {
+		return rule.Prefix
+	}
+	return fmt.Sprintf("protocol=%s host=%s port=%s file=%s", rule.Protocol, rule.Host, rule.Port, rule.File)
+}
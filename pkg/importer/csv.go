@@ -0,0 +1,168 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strings"
+
+	"github.com/root4loot/rescope/pkg/common"
+	"github.com/root4loot/rescope/pkg/normalize"
+)
+
+// Columns maps scope fields to the CSV columns holding them. Empty fields
+// are looked up by the names used in HackerOne and Bugcrowd exports.
+type Columns struct {
+	Identifier string
+	Type       string
+	Bounty     string
+	InScope    string // rows with a false value are out of scope
+	Notes      string
+	Severity   string
+}
+
+// csvColumnNames are the usual names of each column, in order of preference.
+// HackerOne exports use the first of each.
+var csvColumnNames = struct {
+	Identifier, Type, Bounty, InScope, Notes, Severity []string
+}{
+	Identifier: []string{"identifier", "asset_identifier", "target", "asset", "url", "uri"},
+	Type:       []string{"asset_type", "type", "category"},
+	Bounty:     []string{"eligible_for_bounty", "bounty_eligible", "bounty"},
+	InScope:    []string{"eligible_for_submission", "in_scope", "scope"},
+	Notes:      []string{"instruction", "instructions", "notes", "description"},
+	Severity:   []string{"max_severity", "severity"},
+}
+
+// isCSV reports whether the first line of data is a CSV header with a known
+// identifier column
+func isCSV(data []byte) bool {
+	header, _, _ := bytes.Cut(bytes.TrimSpace(data), []byte("\n"))
+	if !bytes.Contains(header, []byte(",")) {
+		return false
+	}
+
+	record, err := csv.NewReader(bytes.NewReader(header)).Read()
+	if err != nil {
+		return false
+	}
+	_, ok := columnIndex(record, "", csvColumnNames.Identifier)
+	return ok
+}
+
+// CSV reads a scope export with a header row, such as HackerOne's structured
+// scope download, into a normalized result. Each row becomes an asset with
+// its metadata.
+func CSV(data []byte, columns Columns) (*common.Result, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CSV: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("CSV has no header row")
+	}
+
+	header := records[0]
+	identifier, ok := columnIndex(header, columns.Identifier, csvColumnNames.Identifier)
+	if !ok {
+		return nil, fmt.Errorf("CSV has no identifier column (set one with --csv-identifier)")
+	}
+
+	optional := func(name string, names []string) int {
+		if index, ok := columnIndex(header, name, names); ok {
+			return index
+		}
+		return -1
+	}
+	assetType := optional(columns.Type, csvColumnNames.Type)
+	bounty := optional(columns.Bounty, csvColumnNames.Bounty)
+	inScope := optional(columns.InScope, csvColumnNames.InScope)
+	notes := optional(columns.Notes, csvColumnNames.Notes)
+	severity := optional(columns.Severity, csvColumnNames.Severity)
+
+	for _, mapped := range []struct{ name, flag string }{
+		{columns.Type, "type"}, {columns.Bounty, "bounty"}, {columns.InScope, "in-scope"},
+		{columns.Notes, "notes"}, {columns.Severity, "severity"},
+	} {
+		if mapped.name == "" {
+			continue
+		}
+		if _, ok := columnIndex(header, mapped.name, nil); !ok {
+			return nil, fmt.Errorf("CSV has no column %q (set with --csv-%s)", mapped.name, mapped.flag)
+		}
+	}
+
+	result := &common.Result{}
+	for _, record := range records[1:] {
+		value := func(index int) string {
+			if index < 0 || index >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[index])
+		}
+
+		id := value(identifier)
+		if id == "" {
+			continue
+		}
+
+		asset := common.Asset{
+			Identifier:  id,
+			Category:    value(assetType),
+			InScope:     true,
+			MaxSeverity: value(severity),
+			Notes:       value(notes),
+		}
+		if eligible, ok := parseBool(value(inScope)); ok {
+			asset.InScope = eligible
+		}
+		if eligible, ok := parseBool(value(bounty)); ok {
+			asset.BountyEligible = &eligible
+		}
+
+		if asset.InScope {
+			result.InScope = append(result.InScope, id)
+		} else {
+			result.OutScope = append(result.OutScope, id)
+		}
+		result.Assets = append(result.Assets, asset)
+	}
+
+	normalize.Result(result)
+	return result, nil
+}
+
+// columnIndex returns the index of the named column, or of the first of
+// names present if name is empty. Names are matched case-insensitively.
+func columnIndex(header []string, name string, names []string) (int, bool) {
+	if name != "" {
+		names = []string{name}
+	}
+
+	for _, candidate := range names {
+		for i, column := range header {
+			column = strings.TrimPrefix(column, "\ufeff") // byte order mark written by spreadsheets
+			if strings.EqualFold(strings.TrimSpace(column), candidate) {
+				return i, true
+			}
+		}
+	}
+	return -1, false
+}
+
+// parseBool parses the yes/no values used in scope exports. It reports false
+// if value is empty or not recognized.
+func parseBool(value string) (bool, bool) {
+	switch strings.ToLower(value) {
+	case "true", "yes", "y", "1", "in", "in scope", "in-scope", "in_scope":
+		return true, true
+	case "false", "no", "n", "0", "out", "out of scope", "out-of-scope", "out_of_scope":
+		return false, true
+	default:
+		return false, false
+	}
+}
@@ -0,0 +1,156 @@
+// Package importer reads scope definitions exported by other tools, such as
+// Burp Suite project options, ZAP contexts and platform CSV exports, back
+// into scope entries.
+package importer
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/root4loot/rescope/pkg/common"
+	"github.com/root4loot/rescope/pkg/normalize"
+)
+
+type Format string
+
+const (
+	FormatBurp Format = "burp"
+	FormatZap  Format = "zap"
+	FormatCSV  Format = "csv"
+)
+
+// Scope is the scope read from a file. Rules that could not be converted to
+// a scope entry are listed in Unparsed.
+type Scope struct {
+	Includes []string
+	Excludes []string
+	Unparsed []string
+}
+
+// Detect returns the format of data, or an empty format if it's not a
+// structured scope file (e.g. a plain list of entries)
+func Detect(data []byte) Format {
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("{")) && isBurp(trimmed):
+		return FormatBurp
+	case bytes.HasPrefix(trimmed, []byte("<")) && bytes.Contains(trimmed, []byte("<context>")):
+		return FormatZap
+	case isCSV(trimmed):
+		return FormatCSV
+	default:
+		return ""
+	}
+}
+
+// Parse reads data in the detected format
+func Parse(data []byte) (*Scope, Format, error) {
+	format := Detect(data)
+
+	var scope *Scope
+	var err error
+	switch format {
+	case FormatBurp:
+		scope, err = Burp(data)
+	case FormatZap:
+		scope, err = Zap(data)
+	case FormatCSV:
+		var result *common.Result
+		if result, err = CSV(data, Columns{}); err == nil {
+			scope = &Scope{Includes: result.InScope, Excludes: result.OutScope, Unparsed: result.Unparsed}
+		}
+	default:
+		return nil, "", fmt.Errorf("unrecognized scope file format")
+	}
+
+	return scope, format, err
+}
+
+// add appends the normalized form of entry to list, or records it as
+// unparsed under rule
+func (s *Scope) add(list *[]string, entry, rule string) {
+	normalized, unparsed := normalize.Normalize([]string{entry})
+	if len(unparsed) > 0 || len(normalized) == 0 {
+		s.Unparsed = append(s.Unparsed, rule)
+		return
+	}
+	*list = append(*list, normalized...)
+}
+
+var (
+	// regex fragments rescope and other tools emit, mapped to glob wildcards
+	regexWildcards = []struct{ regex, glob string }{
+		{`(?:[^.]+\.)*[^.]+`, "*"},
+		{`(?::\d+)?`, ""},
+		{`(?:[/?#].*)?`, ""},
+		{`[^.]*`, "*"},
+		{`[^.]+`, "*"},
+		{`\d{1,3}`, "*"},
+		{`.*`, "*"},
+		{`.+`, "*"},
+	}
+	octetWildcardRegex = regexp.MustCompile(`^(\d{1,3}(?:\.\d{1,3}){0,3})((?:\.\*)+)$`)
+)
+
+// unregex converts a simple regex back into a glob pattern where "*" matches
+// anything. It fails on constructs that have no glob equivalent, such as
+// alternations or character classes.
+func unregex(regex string) (string, error) {
+	regex = strings.TrimSuffix(strings.TrimPrefix(regex, "^"), "$")
+
+	var glob strings.Builder
+	for i := 0; i < len(regex); {
+		matched := false
+		for _, w := range regexWildcards {
+			if strings.HasPrefix(regex[i:], w.regex) {
+				glob.WriteString(w.glob)
+				i += len(w.regex)
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+
+		c := regex[i]
+		switch {
+		case c == '\\' && i+1 < len(regex):
+			glob.WriteByte(regex[i+1])
+			i += 2
+		case strings.IndexByte("()[]{}|+?^$", c) >= 0:
+			return "", fmt.Errorf("unsupported regex construct %q in %q", c, regex)
+		default:
+			// an unescaped "." is taken literally, as in scopes exported
+			// without escaping
+			glob.WriteByte(c)
+			i++
+		}
+	}
+
+	return collapseStars(glob.String()), nil
+}
+
+func collapseStars(s string) string {
+	for strings.Contains(s, "**") {
+		s = strings.ReplaceAll(s, "**", "*")
+	}
+	return s
+}
+
+// globHost converts a host glob to a scope entry host. IPv4 patterns with
+// trailing wildcard octets such as 10.0.*.* become CIDRs.
+func globHost(glob string) string {
+	if matches := octetWildcardRegex.FindStringSubmatch(glob); matches != nil {
+		fixed := strings.Split(matches[1], ".")
+		if len(fixed)+strings.Count(matches[2], "*") == 4 {
+			for len(fixed) < 4 {
+				fixed = append(fixed, "0")
+			}
+			return fmt.Sprintf("%s/%d", strings.Join(fixed, "."), 8*len(strings.Split(matches[1], ".")))
+		}
+	}
+	return glob
+}
@@ -0,0 +1,120 @@
+package importer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnregex(t *testing.T) {
+	tests := []struct {
+		regex    string
+		expected string
+	}{
+		{`^(?:[^.]+\.)*[^.]+\.example\.com$`, "*.example.com"},
+		{`^.*\.example\.com$`, "*.example.com"},
+		{`.*.example.com`, "*.example.com"},
+		{`^api\.example\.com$`, "api.example.com"},
+		{`10\.0\.\d{1,3}\.\d{1,3}`, "10.0.*.*"},
+	}
+
+	for _, test := range tests {
+		glob, err := unregex(test.regex)
+		assert.NoError(t, err, "Expected no error for %q", test.regex)
+		assert.Equal(t, test.expected, glob, "Unexpected glob for %q", test.regex)
+	}
+
+	_, err := unregex(`^(?:api|www)\.example\.com$`)
+	assert.Error(t, err)
+}
+
+func TestBurp(t *testing.T) {
+	data := []byte(`{"target":{"scope":{"advancedMode":true,
+		"include":[
+			{"enabled":true,"protocol":"any","host":"^(?:[^.]+\\.)*[^.]+\\.example\\.com$","port":"","file":""},
+			{"enabled":true,"protocol":"https","host":"^shop\\.example\\.com$","port":"^8443$","file":"^/cart.*"},
+			{"enabled":true,"protocol":"any","host":"10.0.0.0/24","port":"","file":""},
+			{"enabled":false,"protocol":"any","host":"disabled.example.com","port":"","file":""},
+			{"enabled":true,"protocol":"any","host":"^(?:api|www)\\.example\\.org$","port":"","file":""}
+		],
+		"exclude":[
+			{"enabled":true,"protocol":"any","host":".*.staging.example.com","port":"","file":""}
+		]}}}`)
+
+	assert.Equal(t, FormatBurp, Detect(data))
+	assert.Equal(t, Format(""), Detect([]byte(`{"program":{"platform":"HackerOne"},"in_scope":["scope.example.com"],"out_scope":[],"notes":"see \"scope\""}`)), "Expected JSON without Burp's target.scope not to be taken as Burp")
+
+	scope, err := Burp(data)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"*.example.com", "https://shop.example.com:8443/cart", "10.0.0.0/24"}, scope.Includes)
+	assert.Equal(t, []string{"*.staging.example.com"}, scope.Excludes)
+	assert.Len(t, scope.Unparsed, 1)
+
+	simple := []byte(`{"target":{"scope":{"advancedMode":false,
+		"include":[{"enabled":true,"include_subdomains":true,"prefix":"https://example.com"}],
+		"exclude":[{"enabled":true,"include_subdomains":false,"prefix":"http://admin.example.com/login"}]}}}`)
+
+	scope, err = Burp(simple)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"https://*.example.com"}, scope.Includes)
+	assert.Equal(t, []string{"http://admin.example.com/login"}, scope.Excludes)
+}
+
+func TestZap(t *testing.T) {
+	data := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<configuration>
+  <context>
+    <name>example</name>
+    <incregexes>^https?://(?:[^.]+\.)*[^.]+\.example\.com(?::\d+)?(?:[/?#].*)?$</incregexes>
+    <incregexes>^https://shop\.example\.com(?::\d+)?/cart.*$</incregexes>
+    <incregexes>^https?://10\.0\.\d{1,3}\.\d{1,3}(?::\d+)?(?:[/?#].*)?$</incregexes>
+    <incregexes>^http(s)?://example.org:8080.*$</incregexes>
+    <excregexes>^https?://admin\.example\.com(?::\d+)?(?:[/?#].*)?$</excregexes>
+  </context>
+</configuration>`)
+
+	assert.Equal(t, FormatZap, Detect(data))
+
+	scope, err := Zap(data)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"*.example.com", "https://shop.example.com/cart", "10.0.0.0/16", "example.org:8080"}, scope.Includes)
+	assert.Equal(t, []string{"admin.example.com"}, scope.Excludes)
+	assert.Empty(t, scope.Unparsed)
+}
+
+func TestCSV(t *testing.T) {
+	data := []byte("identifier,asset_type,instruction,eligible_for_bounty,eligible_for_submission,max_severity\n" +
+		"*.example.com,WILDCARD,\"All subdomains, except staging\",true,true,critical\n" +
+		"admin.example.com,URL,,false,false,none\n" +
+		"10.0.0.0 - 10.0.0.255,CIDR,,,true,\n")
+
+	assert.Equal(t, FormatCSV, Detect(data))
+	assert.Equal(t, Format(""), Detect([]byte("example.com\nexample.org")))
+
+	result, err := CSV(data, Columns{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"*.example.com", "10.0.0.0/24"}, result.InScope)
+	assert.Equal(t, []string{"admin.example.com"}, result.OutScope)
+	assert.Len(t, result.Assets, 3)
+	assert.Equal(t, "WILDCARD", result.Assets[0].Category)
+	assert.Equal(t, "All subdomains, except staging", result.Assets[0].Notes)
+	assert.Equal(t, "critical", result.Assets[0].MaxSeverity)
+	assert.True(t, *result.Assets[0].BountyEligible)
+	assert.False(t, result.Assets[1].InScope)
+	assert.Nil(t, result.Assets[2].BountyEligible)
+
+	generic := []byte("Host,In scope,Comment\nshop.example.com,yes,production\ntest.example.com,no,\n")
+	assert.Equal(t, Format(""), Detect(generic))
+
+	result, err = CSV(generic, Columns{Identifier: "host", InScope: "In scope", Notes: "Comment"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"shop.example.com"}, result.InScope)
+	assert.Equal(t, []string{"test.example.com"}, result.OutScope)
+	assert.Equal(t, "production", result.Assets[0].Notes)
+
+	_, err = CSV(generic, Columns{})
+	assert.Error(t, err, "Expected error without an identifier column")
+
+	_, err = CSV(generic, Columns{Identifier: "Host", Bounty: "Bounty"})
+	assert.Error(t, err, "Expected error for a missing mapped column")
+}
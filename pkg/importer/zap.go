@@ -0,0 +1,82 @@
+package importer
+
+import (
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/root4loot/rescope/config"
+)
+
+// schemeRegex matches the scheme part of URL regexes, e.g. "https?://",
+// "http(s)?://" or "https://"
+var schemeRegex = regexp.MustCompile(`^(?:(https?)|https\?|http\(s\)\?|\(\?:https\?\)|\.\*|[a-z]+\?|\(\?:https\|http\)|\(https\|http\))(?:\\?:)?(?:\\?/){2}`)
+
+// Zap reads the include and exclude regexes of a ZAP context file
+func Zap(data []byte) (*Scope, error) {
+	var context config.ZapConfig
+	if err := xml.Unmarshal(data, &context); err != nil {
+		return nil, fmt.Errorf("failed to parse ZAP context: %w", err)
+	}
+
+	scope := &Scope{}
+	for _, regex := range context.Context.Incregexes {
+		scope.add(&scope.Includes, urlRegexEntry(regex), regex)
+	}
+	for _, regex := range context.Context.Excregexes {
+		scope.add(&scope.Excludes, urlRegexEntry(regex), regex)
+	}
+
+	return scope, nil
+}
+
+// urlRegexEntry converts a regex matching URLs to a scope entry, or returns an
+// empty string if it can't be expressed as one
+func urlRegexEntry(regex string) string {
+	regex = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(regex), "^"), "$")
+
+	scheme := ""
+	if matches := schemeRegex.FindStringSubmatch(regex); matches != nil {
+		scheme = matches[1]
+		regex = regex[len(matches[0]):]
+	}
+
+	glob, err := unregex(regex)
+	if err != nil {
+		return ""
+	}
+
+	host, path := glob, ""
+	if i := strings.IndexAny(glob, "/?#"); i >= 0 {
+		host, path = glob[:i], glob[i:]
+	}
+
+	port := ""
+	if i := strings.LastIndex(host, ":"); i >= 0 && !strings.Contains(host[i+1:], "]") {
+		host, port = host[:i], host[i:]
+	}
+
+	// a trailing wildcard means anything may follow the host, port or path
+	path = strings.TrimRight(path, "*")
+	port = strings.TrimRight(port, "*")
+
+	if cidr := globHost(host); cidr != host {
+		// CIDRs can't be combined with a port or path
+		if port != "" || path != "" {
+			return ""
+		}
+		return cidr
+	}
+
+	host = strings.TrimRight(host, "*")
+	if host == "" {
+		return ""
+	}
+
+	entry := host + port + path
+	if scheme != "" {
+		entry = scheme + "://" + entry
+	}
+	return entry
+}
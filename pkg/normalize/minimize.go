@@ -0,0 +1,216 @@
+package normalize
+
+import (
+	"net/netip"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Minimize removes redundancy from normalized scope lists. Includes already
+// covered by a broader include are dropped, unless an exclude covers them (the
+// narrower include then documents an exception to that exclude). Overlapping
+// and adjacent IPs and CIDRs are merged into a minimal set. Excludes are
+// reduced the same way.
+func Minimize(includes, excludes []string) (minIncludes, minExcludes []string) {
+	excludeEntries := parseAll(excludes)
+	wildcards := wildcardCache{}
+
+	minIncludes = minimize(includes, wildcards, func(entry Entry) bool {
+		for _, exclude := range excludeEntries {
+			if wildcards.covers(exclude, entry) {
+				return true
+			}
+		}
+		return false
+	})
+
+	minExcludes = minimize(excludes, wildcards, func(Entry) bool { return false })
+
+	return minIncludes, minExcludes
+}
+
+func minimize(items []string, wildcards wildcardCache, protected func(Entry) bool) []string {
+	var entries []Entry
+	var prefixes, protectedPrefixes []netip.Prefix
+	var result []string
+
+	for _, item := range items {
+		entry, err := Parse(item)
+		if err != nil {
+			result = append(result, item)
+			continue
+		}
+
+		switch entry.Kind {
+		case KindIP, KindCIDR:
+			prefix, ok := toPrefix(entry)
+			if !ok {
+				result = append(result, item)
+			} else if protected(entry) {
+				protectedPrefixes = append(protectedPrefixes, prefix)
+			} else {
+				prefixes = append(prefixes, prefix)
+			}
+		default:
+			entries = append(entries, entry)
+		}
+	}
+
+	for i, entry := range entries {
+		redundant := false
+		if !protected(entry) {
+			for j, broader := range entries {
+				if i == j {
+					continue
+				}
+				// identical entries can't occur after normalization, but guard
+				// against mutual coverage by keeping the first of the two
+				if wildcards.covers(broader, entry) && (!wildcards.covers(entry, broader) || j < i) {
+					redundant = true
+					break
+				}
+			}
+		}
+
+		if !redundant {
+			result = append(result, entry.String())
+		}
+	}
+
+	for _, prefix := range mergePrefixes(prefixes) {
+		result = append(result, prefixString(prefix))
+	}
+
+	for _, prefix := range protectedPrefixes {
+		result = append(result, prefixString(prefix))
+	}
+
+	return result
+}
+
+// Covers reports whether every target matched by entry n is also matched by b
+func Covers(b, n Entry) bool {
+	return wildcardCache{}.covers(b, n)
+}
+
+// wildcardCache holds the compiled regexes of wildcard host patterns, so that
+// comparing many entries compiles each pattern once
+type wildcardCache map[string]*regexp.Regexp
+
+func (c wildcardCache) covers(b, n Entry) bool {
+	bPrefix, bIsIP := toPrefix(b)
+	nPrefix, nIsIP := toPrefix(n)
+	if bIsIP || nIsIP {
+		return bIsIP && nIsIP && bPrefix.Bits() <= nPrefix.Bits() && bPrefix.Contains(nPrefix.Addr())
+	}
+
+	if b.Kind == KindURL {
+		if b.Scheme != "" && b.Scheme != n.Scheme {
+			return false
+		}
+		if b.Host != n.Host || b.Port != n.Port {
+			return false
+		}
+		if b.Path == "" {
+			return true
+		}
+		if !strings.HasPrefix(n.Path, b.Path) {
+			return false
+		}
+		rest := n.Path[len(b.Path):]
+		return rest == "" || rest[0] == '/' || rest[0] == '?'
+	}
+
+	if b.Port != "" && b.Port != n.Port {
+		return false
+	}
+
+	return c.hostCovers(b.Host, n.Host)
+}
+
+// hostCovers reports whether the host pattern matches host
+func (c wildcardCache) hostCovers(pattern, host string) bool {
+	if pattern == host {
+		return true
+	}
+
+	if !strings.Contains(pattern, "*") {
+		return false
+	}
+
+	re, ok := c[pattern]
+	if !ok {
+		re = regexp.MustCompile("^" + HostRegex(pattern) + "$")
+		c[pattern] = re
+	}
+	return re.MatchString(host)
+}
+
+// mergePrefixes returns the minimal set of prefixes covering the same
+// addresses as prefixes
+func mergePrefixes(prefixes []netip.Prefix) []netip.Prefix {
+	sort.Slice(prefixes, func(i, j int) bool {
+		if prefixes[i].Addr() == prefixes[j].Addr() {
+			return prefixes[i].Bits() < prefixes[j].Bits()
+		}
+		return prefixes[i].Addr().Less(prefixes[j].Addr())
+	})
+
+	// collapse into non-overlapping ranges, then split back into prefixes
+	type span struct{ start, end netip.Addr }
+	var spans []span
+
+	for _, prefix := range prefixes {
+		start, end := prefix.Addr(), lastAddr(prefix)
+		if n := len(spans); n > 0 {
+			last := &spans[n-1]
+			if last.end.BitLen() == start.BitLen() && (!last.end.Less(start) || last.end.Next() == start) {
+				if last.end.Less(end) {
+					last.end = end
+				}
+				continue
+			}
+		}
+		spans = append(spans, span{start, end})
+	}
+
+	var merged []netip.Prefix
+	for _, s := range spans {
+		merged = append(merged, rangeToPrefixes(s.start, s.end)...)
+	}
+	return merged
+}
+
+func parseAll(items []string) []Entry {
+	var entries []Entry
+	for _, item := range items {
+		if entry, err := Parse(item); err == nil {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func toPrefix(entry Entry) (netip.Prefix, bool) {
+	switch entry.Kind {
+	case KindIP:
+		addr, err := netip.ParseAddr(entry.Host)
+		if err != nil {
+			return netip.Prefix{}, false
+		}
+		return netip.PrefixFrom(addr, addr.BitLen()), true
+	case KindCIDR:
+		prefix, err := netip.ParsePrefix(entry.Host)
+		return prefix, err == nil
+	}
+	return netip.Prefix{}, false
+}
+
+// prefixString formats single-address prefixes as plain IPs
+func prefixString(prefix netip.Prefix) string {
+	if prefix.IsSingleIP() {
+		return prefix.Addr().String()
+	}
+	return prefix.String()
+}
@@ -0,0 +1,347 @@
+// Package normalize canonicalizes scope identifiers as returned by the
+// bug bounty platforms and custom scope lists.
+package normalize
+
+import (
+	"fmt"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/root4loot/rescope/pkg/common"
+	"golang.org/x/net/idna"
+)
+
+type Kind string
+
+const (
+	KindDomain   Kind = "domain"
+	KindWildcard Kind = "wildcard"
+	KindURL      Kind = "url"
+	KindIP       Kind = "ip"
+	KindCIDR     Kind = "cidr"
+)
+
+// Entry is a parsed scope identifier
+type Entry struct {
+	Raw    string
+	Kind   Kind
+	Scheme string
+	Host   string
+	Port   string
+	Path   string
+}
+
+var (
+	notesRegex   = regexp.MustCompile(`\s*\(.*?\)\s*`)
+	ipRangeRegex = regexp.MustCompile(`^(\d{1,3}(?:\.\d{1,3}){3}|[0-9a-fA-F]*:[0-9a-fA-F:.]+)\s*-\s*(\d{1,3}(?:\.\d{1,3}){3}|\d{1,3}|[0-9a-fA-F]*:[0-9a-fA-F:.]+)$`)
+	labelRegex   = regexp.MustCompile(`^[a-z0-9_*]([a-z0-9_*-]*[a-z0-9_*])?$`)
+	defaultPorts = map[string]string{"http": "80", "https": "443"}
+)
+
+// String returns the canonical form of the entry
+func (e Entry) String() string {
+	switch e.Kind {
+	case KindIP, KindCIDR:
+		return e.Host
+	}
+
+	var builder strings.Builder
+	if e.Scheme != "" {
+		builder.WriteString(e.Scheme)
+		builder.WriteString("://")
+	}
+	builder.WriteString(e.Host)
+	if e.Port != "" {
+		builder.WriteString(":")
+		builder.WriteString(e.Port)
+	}
+	builder.WriteString(e.Path)
+	return builder.String()
+}
+
+// Parse parses and canonicalizes a single scope identifier. IP ranges are
+// rejected; use Normalize to have them converted to CIDRs.
+func Parse(raw string) (Entry, error) {
+	entry := Entry{Raw: raw}
+	input := clean(raw)
+
+	if input == "" || strings.ContainsAny(input, " \t") {
+		return entry, fmt.Errorf("unrecognized scope entry: %q", raw)
+	}
+
+	if addr, err := netip.ParseAddr(input); err == nil {
+		entry.Kind = KindIP
+		entry.Host = addr.String()
+		return entry, nil
+	}
+
+	if prefix, err := netip.ParsePrefix(input); err == nil {
+		entry.Kind = KindCIDR
+		if prefix.IsSingleIP() {
+			entry.Kind = KindIP
+		}
+		entry.Host = prefixString(prefix.Masked())
+		return entry, nil
+	}
+
+	hasScheme := strings.Contains(input, "://")
+	if !hasScheme {
+		input = "placeholder://" + input
+	}
+
+	u, err := url.Parse(input)
+	if err != nil || u.Host == "" {
+		return entry, fmt.Errorf("unrecognized scope entry: %q", raw)
+	}
+
+	if hasScheme {
+		entry.Scheme = strings.ToLower(u.Scheme)
+	}
+
+	entry.Port = u.Port()
+	if entry.Port != "" && defaultPorts[entry.Scheme] == entry.Port {
+		entry.Port = ""
+	}
+
+	entry.Path = strings.TrimRight(u.EscapedPath(), "/")
+	if u.RawQuery != "" {
+		entry.Path += "?" + u.RawQuery
+	}
+
+	hostname := u.Hostname()
+	if addr, err := netip.ParseAddr(hostname); err == nil {
+		entry.Host = addr.String()
+		if addr.Is6() {
+			entry.Host = "[" + entry.Host + "]"
+		}
+	} else {
+		entry.Host, err = Host(hostname)
+		if err != nil {
+			return entry, fmt.Errorf("unrecognized scope entry %q: %w", raw, err)
+		}
+	}
+
+	switch {
+	case entry.Scheme != "" || entry.Path != "":
+		entry.Kind = KindURL
+	case strings.Contains(entry.Host, "*"):
+		entry.Kind = KindWildcard
+	default:
+		entry.Kind = KindDomain
+	}
+
+	return entry, nil
+}
+
+// Host canonicalizes a hostname: lowercase, no trailing dot and
+// internationalized labels converted to punycode. Wildcard labels are kept.
+func Host(host string) (string, error) {
+	host = strings.TrimSuffix(strings.TrimSpace(host), ".")
+	if host == "" {
+		return "", fmt.Errorf("empty host")
+	}
+
+	labels := strings.Split(host, ".")
+	if len(labels) < 2 {
+		return "", fmt.Errorf("host %q has no top-level domain", host)
+	}
+
+	for i, label := range labels {
+		if !isASCII(label) {
+			ascii, err := idna.Lookup.ToASCII(label)
+			if err != nil {
+				return "", fmt.Errorf("invalid label %q: %w", label, err)
+			}
+			label = ascii
+		}
+
+		label = strings.ToLower(label)
+		if !labelRegex.MatchString(label) {
+			return "", fmt.Errorf("invalid label %q", label)
+		}
+		labels[i] = label
+	}
+
+	canonical := strings.Join(labels, ".")
+	if strings.Trim(canonical, "*.") == "" {
+		return "", fmt.Errorf("host %q matches everything", host)
+	}
+
+	return canonical, nil
+}
+
+// IPRangeToCIDRs converts an IP range such as "10.0.0.0 - 10.0.0.255" to the
+// minimal list of CIDRs covering it. Single addresses are listed as plain IPs,
+// as Minimize lists them.
+func IPRangeToCIDRs(ipRange string) ([]string, error) {
+	matches := ipRangeRegex.FindStringSubmatch(strings.TrimSpace(ipRange))
+	if matches == nil {
+		return nil, fmt.Errorf("invalid IP range: %q", ipRange)
+	}
+
+	start, err := netip.ParseAddr(matches[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid IP range start: %w", err)
+	}
+
+	end, err := netip.ParseAddr(matches[2])
+	if err != nil {
+		// allow shorthand such as 10.0.0.1-254
+		if start.Is4() && !strings.Contains(matches[2], ".") {
+			end, err = netip.ParseAddr(shorthandEnd(start, matches[2]))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid IP range end: %w", err)
+		}
+	}
+
+	if start.BitLen() != end.BitLen() || end.Less(start) {
+		return nil, fmt.Errorf("invalid IP range: %q", ipRange)
+	}
+
+	var cidrs []string
+	for _, prefix := range rangeToPrefixes(start, end) {
+		cidrs = append(cidrs, prefixString(prefix))
+	}
+	return cidrs, nil
+}
+
+// Normalize canonicalizes a list of scope identifiers, converting IP ranges to
+// CIDRs and removing duplicates. Entries that could not be parsed are returned
+// separately instead of being passed through.
+func Normalize(items []string) (normalized, unparsed []string) {
+	seen := make(map[string]bool)
+
+	add := func(item string) {
+		if !seen[item] {
+			seen[item] = true
+			normalized = append(normalized, item)
+		}
+	}
+
+	for _, item := range items {
+		cleaned := clean(item)
+
+		if ipRangeRegex.MatchString(cleaned) {
+			cidrs, err := IPRangeToCIDRs(cleaned)
+			if err != nil {
+				unparsed = append(unparsed, item)
+				continue
+			}
+			for _, cidr := range cidrs {
+				add(cidr)
+			}
+			continue
+		}
+
+		entry, err := Parse(item)
+		if err != nil {
+			unparsed = append(unparsed, item)
+			continue
+		}
+		add(entry.String())
+	}
+
+	return normalized, unparsed
+}
+
+// Result normalizes the in-scope and out-of-scope entries of result in place.
+// Entries that could not be parsed are appended to result.Unparsed. Asset
+// identifiers are canonicalized the same way so they keep matching the lists.
+func Result(result *common.Result) {
+	var unparsedIn, unparsedOut []string
+	result.InScope, unparsedIn = Normalize(result.InScope)
+	result.OutScope, unparsedOut = Normalize(result.OutScope)
+	result.Unparsed = append(result.Unparsed, unparsedIn...)
+	result.Unparsed = append(result.Unparsed, unparsedOut...)
+	result.Assets = Assets(result.Assets)
+}
+
+// Assets canonicalizes asset identifiers and sets their type from the parsed
+// kind. Assets holding an IP range are split into one asset per CIDR, and
+// assets that can't be parsed are dropped.
+func Assets(assets []common.Asset) []common.Asset {
+	seen := make(map[string]bool)
+	var normalized []common.Asset
+
+	for _, asset := range assets {
+		values, _ := Normalize([]string{asset.Identifier})
+		for _, value := range values {
+			key := fmt.Sprintf("%t %s", asset.InScope, value)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			entry, _ := Parse(value)
+			asset.Identifier = value
+			asset.Type = string(entry.Kind)
+			normalized = append(normalized, asset)
+		}
+	}
+
+	return normalized
+}
+
+// clean strips surrounding noise such as notes in parentheses, quotes and
+// trailing backslashes
+func clean(raw string) string {
+	s := strings.TrimSpace(raw)
+	s = notesRegex.ReplaceAllString(s, " ")
+	s = strings.TrimSpace(s)
+	s = strings.Trim(s, "\"'`")
+	s = strings.TrimRight(s, `\,;`)
+	return strings.TrimSpace(s)
+}
+
+func shorthandEnd(start netip.Addr, last string) string {
+	octets := strings.Split(start.String(), ".")
+	octets[3] = last
+	return strings.Join(octets, ".")
+}
+
+func rangeToPrefixes(start, end netip.Addr) []netip.Prefix {
+	var prefixes []netip.Prefix
+
+	for {
+		bits := start.BitLen()
+		for bits > 0 {
+			candidate := netip.PrefixFrom(start, bits-1)
+			if candidate.Masked().Addr() != start || end.Less(lastAddr(candidate)) {
+				break
+			}
+			bits--
+		}
+
+		prefix := netip.PrefixFrom(start, bits)
+		prefixes = append(prefixes, prefix)
+
+		last := lastAddr(prefix)
+		if last == end || !last.Next().IsValid() {
+			return prefixes
+		}
+		start = last.Next()
+	}
+}
+
+// lastAddr returns the last address in prefix
+func lastAddr(prefix netip.Prefix) netip.Addr {
+	addr := prefix.Masked().Addr()
+	bytes := addr.AsSlice()
+	for i := prefix.Bits(); i < len(bytes)*8; i++ {
+		bytes[i/8] |= 1 << (7 - uint(i%8))
+	}
+	last, _ := netip.AddrFromSlice(bytes)
+	return last
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] > 127 {
+			return false
+		}
+	}
+	return true
+}
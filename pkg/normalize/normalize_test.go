@@ -0,0 +1,150 @@
+package normalize
+
+import (
+	"testing"
+
+	"github.com/root4loot/rescope/pkg/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError bool
+		expected      string
+		expectedKind  Kind
+	}{
+		{"https://www.example.com/", false, "https://www.example.com", KindURL},
+		{"HTTPS://Example.com:443/api/", false, "https://example.com/api", KindURL},
+		{"*.Example.COM", false, "*.example.com", KindWildcard},
+		{"example.com.", false, "example.com", KindDomain},
+		{"example.com (staging excluded)", false, "example.com", KindDomain},
+		{`api.example.com\`, false, "api.example.com", KindDomain},
+		{"example.com:8080", false, "example.com:8080", KindDomain},
+		{"münchen.de", false, "xn--mnchen-3ya.de", KindDomain},
+		{"booking.*.sqills.com", false, "booking.*.sqills.com", KindWildcard},
+		{"10.0.0.5/24", false, "10.0.0.0/24", KindCIDR},
+		{"192.168.1.1", false, "192.168.1.1", KindIP},
+		{"192.168.1.1/32", false, "192.168.1.1", KindIP},
+		{"2001:db8::1/128", false, "2001:db8::1", KindIP},
+		{"*", true, "", ""},
+		{"Any host verified as belonging to Example", true, "", ""},
+		{"123456789", true, "", ""},
+	}
+
+	for _, test := range tests {
+		entry, err := Parse(test.input)
+		if test.expectedError {
+			assert.Error(t, err, "Expected error for %q", test.input)
+			continue
+		}
+
+		assert.NoError(t, err, "Expected no error for %q", test.input)
+		assert.Equal(t, test.expected, entry.String(), "Unexpected canonical form for %q", test.input)
+		assert.Equal(t, test.expectedKind, entry.Kind, "Unexpected kind for %q", test.input)
+	}
+}
+
+func TestIPRangeToCIDRs(t *testing.T) {
+	cidrs, err := IPRangeToCIDRs("10.0.0.0 - 10.0.0.255")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.0/24"}, cidrs)
+
+	cidrs, err = IPRangeToCIDRs("10.0.0.1-10.0.0.6")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2/31", "10.0.0.4/31", "10.0.0.6"}, cidrs)
+
+	cidrs, err = IPRangeToCIDRs("192.168.1.1 - 192.168.1.1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"192.168.1.1"}, cidrs, "Expected a single address as a plain IP, as with Minimize")
+
+	cidrs, err = IPRangeToCIDRs("10.0.0.0-127")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.0/25"}, cidrs)
+
+	_, err = IPRangeToCIDRs("10.0.0.9-10.0.0.1")
+	assert.Error(t, err)
+}
+
+func TestNormalize(t *testing.T) {
+	normalized, unparsed := Normalize([]string{
+		"https://www.example.com/",
+		"https://WWW.example.com",
+		"*.example.com",
+		"*.EXAMPLE.com",
+		"10.0.0.0 - 10.0.0.255",
+		"dead-beef.ca",
+		"see program policy",
+	})
+
+	assert.Equal(t, []string{"https://www.example.com", "*.example.com", "10.0.0.0/24", "dead-beef.ca"}, normalized)
+	assert.Equal(t, []string{"see program policy"}, unparsed)
+}
+
+func TestMinimize(t *testing.T) {
+	includes := []string{
+		"*.example.com",
+		"api.example.com",
+		"https://shop.example.com/cart",
+		"admin.staging.example.com",
+		"example.com",
+		"https://example.org/api",
+		"https://example.org/api/v1",
+		"10.0.0.0/25",
+		"10.0.0.128/25",
+		"10.0.0.5",
+		"10.0.1.0",
+		"192.168.0.0/24",
+	}
+	excludes := []string{
+		"*.staging.example.com",
+		"192.168.0.0/16",
+		"192.168.0.10",
+	}
+
+	minIncludes, minExcludes := Minimize(includes, excludes)
+
+	assert.Equal(t, []string{
+		"*.example.com",
+		"admin.staging.example.com",
+		"example.com",
+		"https://example.org/api",
+		"10.0.0.0/24",
+		"10.0.1.0",
+		"192.168.0.0/24",
+	}, minIncludes)
+	assert.Equal(t, []string{"*.staging.example.com", "192.168.0.0/16"}, minExcludes)
+}
+
+func TestCIDRRegex(t *testing.T) {
+	tests := []struct {
+		cidr     string
+		expected string
+	}{
+		{"10.0.0.0/24", `10\.0\.0\.\d{1,3}`},
+		{"10.0.0.0/23", `10\.0\.(?:0|1)\.\d{1,3}`},
+		{"192.168.1.5", `192\.168\.1\.5`},
+	}
+
+	for _, test := range tests {
+		regex, err := CIDRRegex(test.cidr)
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, regex)
+	}
+
+	_, err := CIDRRegex("2001:db8::/32")
+	assert.Error(t, err)
+}
+
+func TestAssets(t *testing.T) {
+	assets := Assets([]common.Asset{
+		{Identifier: "HTTPS://Example.com/", Category: "URL", InScope: true},
+		{Identifier: "10.0.0.0 - 10.0.1.255", Category: "IP-RANGE", InScope: true},
+		{Identifier: "see policy", InScope: true},
+	})
+
+	assert.Equal(t, []common.Asset{
+		{Identifier: "https://example.com", Type: "url", Category: "URL", InScope: true},
+		{Identifier: "10.0.0.0/23", Type: "cidr", Category: "IP-RANGE", InScope: true},
+	}, assets)
+}
@@ -0,0 +1,67 @@
+package normalize
+
+import (
+	"fmt"
+	"net/netip"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// HostRegex returns an unanchored regular expression matching host. A leading
+// "*" label matches one or more labels, any other "*" matches within a label.
+func HostRegex(host string) string {
+	var builder strings.Builder
+
+	labels := strings.Split(host, ".")
+	for i, label := range labels {
+		if i == 0 && label == "*" {
+			builder.WriteString(`(?:[^.]+\.)*[^.]+`)
+			continue
+		}
+		if i > 0 {
+			builder.WriteString(`\.`)
+		}
+		builder.WriteString(strings.ReplaceAll(regexp.QuoteMeta(label), `\*`, `[^.]*`))
+	}
+
+	return builder.String()
+}
+
+// CIDRRegex returns an unanchored regular expression matching the IPv4
+// addresses in cidr
+func CIDRRegex(cidr string) (string, error) {
+	prefix, err := netip.ParsePrefix(cidr)
+	if err != nil {
+		if addr, addrErr := netip.ParseAddr(cidr); addrErr == nil {
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		} else {
+			return "", err
+		}
+	}
+
+	if !prefix.Addr().Is4() {
+		return "", fmt.Errorf("only IPv4 CIDRs can be converted to a regex: %s", cidr)
+	}
+
+	first := prefix.Masked().Addr().As4()
+	last := lastAddr(prefix).As4()
+
+	octets := make([]string, 4)
+	for i := range octets {
+		switch {
+		case first[i] == last[i]:
+			octets[i] = strconv.Itoa(int(first[i]))
+		case first[i] == 0 && last[i] == 255:
+			octets[i] = `\d{1,3}`
+		default:
+			var values []string
+			for v := int(first[i]); v <= int(last[i]); v++ {
+				values = append(values, strconv.Itoa(v))
+			}
+			octets[i] = "(?:" + strings.Join(values, "|") + ")"
+		}
+	}
+
+	return strings.Join(octets, `\.`), nil
+}
@@ -0,0 +1,151 @@
+// Package redact masks credentials, such as tokens, session cookies, CSRF
+// values and Authorization headers, in text and HTTP headers so that logs and
+// traces can be shared.
+package redact
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/root4loot/goutils/log"
+	"github.com/sirupsen/logrus"
+)
+
+// Mask replaces redacted values
+const Mask = "[REDACTED]"
+
+// sensitiveHeaders are the headers whose values are masked
+var sensitiveHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Auth-Token",
+	"X-Csrf-Token",
+	"X-Api-Key",
+}
+
+// patterns match credentials in text. The first group is kept, the rest of
+// the match is masked.
+var patterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)(\b(?:` + strings.Join(sensitiveHeaders, "|") + `):[ \t]*)[^\r\n"]+`),
+	regexp.MustCompile(`(?i)(\bbearer\s+)[\w\-.~+/]+=*`),
+	regexp.MustCompile(`(?i)(\b(?:_bugcrowd_session|__Host-session|session|csrf[_-]?token|authenticity_token|access_token|token)=)"?[^;"\s&]+"?`),
+	regexp.MustCompile(`(?i)("(?:csrf_?token|csrfToken|authenticity_token|access_token|refresh_token|token|password|secret)"\s*:\s*)"[^"]*"`),
+	regexp.MustCompile(`(?i)(<meta\s+name="csrf-token"\s+content=")[^"]*`),
+}
+
+var (
+	mu      sync.RWMutex
+	secrets []string
+)
+
+// Add registers values to mask wherever they appear, such as the tokens
+// passed to rescope. Empty values are ignored.
+func Add(values ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, value := range values {
+		if value == "" || contains(secrets, value) {
+			continue
+		}
+		secrets = append(secrets, value)
+	}
+
+	// mask longer secrets first, so that a secret containing another is
+	// masked whole
+	sort.Slice(secrets, func(i, j int) bool { return len(secrets[i]) > len(secrets[j]) })
+}
+
+// String masks the registered secrets and anything that looks like a
+// credential in s
+func String(s string) string {
+	mu.RLock()
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, Mask)
+	}
+	mu.RUnlock()
+
+	for _, pattern := range patterns {
+		s = pattern.ReplaceAllString(s, "${1}"+Mask)
+	}
+	return s
+}
+
+// Secret returns Mask for a set credential and "" for an unset one, to show
+// whether a credential is set without revealing it
+func Secret(value string) string {
+	if value == "" {
+		return ""
+	}
+	return Mask
+}
+
+// Header returns a copy of h with the values of sensitive headers masked
+func Header(h http.Header) http.Header {
+	redacted := h.Clone()
+	for _, name := range sensitiveHeaders {
+		for i := range redacted[name] {
+			redacted[name][i] = Mask
+		}
+	}
+	return redacted
+}
+
+// Hook is a logrus hook masking credentials in the message of every log
+// entry. Fields needn't be masked, as the formatter only prints the message.
+type Hook struct{}
+
+func (Hook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (Hook) Fire(entry *logrus.Entry) error {
+	entry.Message = String(entry.Message)
+	return nil
+}
+
+var (
+	hookMu sync.Mutex
+	hooked = make(map[*logrus.Logger]bool)
+)
+
+// InstallHook adds Hook to the global logger unless it has it already. It is
+// added per logger, as log.Init replaces the global logger.
+func InstallHook() {
+	logger := globalLogger()
+	if logger == nil {
+		return
+	}
+
+	hookMu.Lock()
+	defer hookMu.Unlock()
+	if !hooked[logger] {
+		logger.AddHook(Hook{})
+		hooked[logger] = true
+	}
+}
+
+// globalLogger returns the global logger, or nil if log.Init wasn't called,
+// in which case nothing is logged
+func globalLogger() (logger *logrus.Logger) {
+	defer func() {
+		if recover() != nil {
+			logger = nil
+		}
+	}()
+	return log.WithFields(nil).Logger
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
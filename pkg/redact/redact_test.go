@@ -0,0 +1,56 @@
+package redact
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestString(t *testing.T) {
+	Add("s3cr3t-token-value", "")
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Authorization: Bearer abc.def", "Authorization: [REDACTED]"},
+		{"cookie: _bugcrowd_session=abc; other=1", "cookie: [REDACTED]"},
+		{`curl -H "X-Auth-Token: abc"`, `curl -H "X-Auth-Token: [REDACTED]"`},
+		{"sent bearer eyJhbGciOi.payload.sig to api", "sent bearer [REDACTED] to api"},
+		{"Set cookie __Host-session=abc123; path=/", "Set cookie __Host-session=[REDACTED]; path=/"},
+		{`{"csrf_token":"abc","name":"acme"}`, `{"csrf_token":[REDACTED],"name":"acme"}`},
+		{`<meta name="csrf-token" content="abc+/=">`, `<meta name="csrf-token" content="[REDACTED]">`},
+		{"failed with token s3cr3t-token-value", "failed with token [REDACTED]"},
+		{"https://example.com/?access_token=abc&page=2", "https://example.com/?access_token=[REDACTED]&page=2"},
+		{"nothing to hide", "nothing to hide"},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, String(test.input), "Unexpected redaction of %q", test.input)
+	}
+}
+
+func TestHeader(t *testing.T) {
+	h := http.Header{}
+	h.Set("Authorization", "Bearer abc")
+	h.Add("Set-Cookie", "a=1")
+	h.Add("Set-Cookie", "b=2")
+	h.Set("Content-Type", "application/json")
+
+	redacted := Header(h)
+	assert.Equal(t, []string{Mask}, redacted.Values("Authorization"))
+	assert.Equal(t, []string{Mask, Mask}, redacted.Values("Set-Cookie"))
+	assert.Equal(t, "application/json", redacted.Get("Content-Type"))
+	assert.Equal(t, "Bearer abc", h.Get("Authorization"), "Expected the original header to be unchanged")
+
+	assert.Equal(t, "", Secret(""))
+	assert.Equal(t, Mask, Secret("abc"))
+}
+
+func TestHook(t *testing.T) {
+	entry := &logrus.Entry{Message: "Authorization: Bearer abc"}
+	assert.NoError(t, Hook{}.Fire(entry))
+	assert.Equal(t, "Authorization: [REDACTED]", entry.Message)
+}
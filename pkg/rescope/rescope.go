@@ -1,20 +1,40 @@
 package rescope
 
 import (
+	stderrors "errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
 	"github.com/root4loot/goutils/domainutil"
 	"github.com/root4loot/goutils/log"
+	"github.com/root4loot/goutils/sliceutil"
 
 	"github.com/root4loot/rescope/pkg/bugbounty/bugcrowd"
 	"github.com/root4loot/rescope/pkg/bugbounty/hackerone"
 	"github.com/root4loot/rescope/pkg/bugbounty/intigriti"
+	"github.com/root4loot/rescope/pkg/bugbounty/securitytxt"
 	"github.com/root4loot/rescope/pkg/bugbounty/yeswehack"
 	"github.com/root4loot/rescope/pkg/common"
+	"github.com/root4loot/rescope/pkg/normalize"
+	"github.com/root4loot/rescope/pkg/redact"
+	"github.com/root4loot/rescope/pkg/trace"
+)
+
+// Errors returned by Run. Compare with errors.Is.
+var (
+	ErrProgramNotFound = common.ErrProgramNotFound
+	ErrAuthRequired    = common.ErrAuthRequired
+	ErrAuthInvalid     = common.ErrAuthInvalid
+	ErrAuthExpired     = common.ErrAuthExpired
+	ErrRateLimited     = common.ErrRateLimited
+	ErrParse           = common.ErrParse
+	ErrEmptyScope      = common.ErrEmptyScope
 )
 
 type Result interface {
@@ -27,13 +47,143 @@ type BugBountyProgram interface {
 	Serialize() (string, error)
 }
 
+// ProgramLister is implemented by the platforms that can list the programs
+// visible to the configured account
+type ProgramLister interface {
+	ListPrograms(client *http.Client) ([]common.ProgramSummary, error)
+}
+
+// AuthChecker is implemented by the platforms that can verify their
+// credentials. CheckAuth returns the account name if the platform reports it.
+type AuthChecker interface {
+	CheckAuth(client *http.Client) (string, error)
+}
+
+// ProgramFilter selects the programs returned by ListPrograms. The zero value
+// selects every program on every platform.
+type ProgramFilter struct {
+	Platforms    []string // platform names, case insensitive
+	BountyOnly   bool
+	PrivateOnly  bool
+	FollowedOnly bool
+}
+
+// Match reports whether program is selected by the filter
+func (f ProgramFilter) Match(program common.ProgramSummary) bool {
+	if len(f.Platforms) > 0 && !containsFold(f.Platforms, program.Platform) {
+		return false
+	}
+	return (!f.BountyOnly || program.Bounty) &&
+		(!f.PrivateOnly || program.Private) &&
+		(!f.FollowedOnly || program.Following)
+}
+
 type Options struct {
-	Client        *http.Client
-	AuthHackerOne string
-	AuthIntigriti string
-	AuthBugcrowd  string
-	AuthYesWeHack string
-	Debug         bool
+	Client         *http.Client
+	AuthHackerOne  string
+	AuthIntigriti  string
+	AuthBugcrowd   string
+	AuthYesWeHack  string
+	UserHackerOne  string // account names, to tell the accounts of several profiles apart
+	UserIntigriti  string
+	UserBugcrowd   string
+	UserYesWeHack  string
+	Profile        string            // name of the credential profile the options were taken from, if any
+	SecurityTxtKey string            // armored OpenPGP public key to verify signed security.txt files
+	BaseURLs       map[string]string // base URL to send a platform's requests to instead, by platform name (e.g. "intigriti")
+	Trace          io.Writer         // if set, requests and responses are written here with credentials redacted
+	StrictAuth     bool              // verify credentials before using them, and fail rather than fall back to public data
+	Debug          bool
+}
+
+// String describes the options with credentials redacted, as logged in
+// debug mode
+func (o *Options) String() string {
+	return fmt.Sprintf("{Profile:%s AuthHackerOne:%s AuthIntigriti:%s AuthBugcrowd:%s AuthYesWeHack:%s UserHackerOne:%s UserIntigriti:%s UserBugcrowd:%s UserYesWeHack:%s SecurityTxtKey:%t BaseURLs:%v Trace:%t StrictAuth:%t Debug:%t}",
+		o.Profile, redact.Secret(o.AuthHackerOne), redact.Secret(o.AuthIntigriti), redact.Secret(o.AuthBugcrowd), redact.Secret(o.AuthYesWeHack),
+		o.UserHackerOne, o.UserIntigriti, o.UserBugcrowd, o.UserYesWeHack, o.SecurityTxtKey != "", o.BaseURLs, o.Trace != nil, o.StrictAuth, o.Debug)
+}
+
+// redactSecrets registers the credentials in the options to be masked in
+// log output and traces, and masks them in log output from then on
+func (o *Options) redactSecrets() {
+	redact.Add(o.AuthHackerOne, o.AuthIntigriti, o.AuthBugcrowd, o.AuthYesWeHack)
+	redact.InstallHook()
+}
+
+// platformDomains maps the platform names used in Options.BaseURLs to the
+// root domain of their hosts
+var platformDomains = map[string]string{
+	"hackerone": "hackerone.com",
+	"bugcrowd":  "bugcrowd.com",
+	"intigriti": "intigriti.com",
+	"yeswehack": "yeswehack.com",
+}
+
+// httpClient returns the client to send requests with. Requests to platforms
+// with a base URL are sent there instead, and traced if Trace is set.
+func (o *Options) httpClient() *http.Client {
+	client := o.Client
+	if client == nil {
+		client = &http.Client{}
+	}
+	if len(o.BaseURLs) == 0 && o.Trace == nil {
+		return client
+	}
+
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if o.Trace != nil {
+		base = &trace.Transport{Base: base, Out: o.Trace}
+	}
+
+	wrapped := *client
+	wrapped.Transport = base
+	if len(o.BaseURLs) == 0 {
+		return &wrapped
+	}
+
+	transport := &baseURLTransport{transport: base, baseURLs: make(map[string]*url.URL)}
+
+	for platform, baseURL := range o.BaseURLs {
+		domain, ok := platformDomains[strings.ToLower(platform)]
+		if !ok {
+			log.Warn("Ignoring base URL of unknown platform", "platform", platform)
+			continue
+		}
+		u, err := url.Parse(baseURL)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			log.Warn("Ignoring invalid base URL", "platform", platform, "url", baseURL)
+			continue
+		}
+		transport.baseURLs[domain] = u
+	}
+
+	wrapped.Transport = transport
+	return &wrapped
+}
+
+// baseURLTransport sends requests to a platform's hosts to the base URL set
+// for the platform, keeping their path and query
+type baseURLTransport struct {
+	transport http.RoundTripper
+	baseURLs  map[string]*url.URL // by root domain
+}
+
+func (t *baseURLTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base, ok := t.baseURLs[domainutil.GetRootDomain(req.URL.Hostname())]
+	if !ok {
+		return t.transport.RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context())
+	req.URL.Scheme = base.Scheme
+	req.URL.Host = base.Host
+	req.URL.Path = strings.TrimRight(base.Path, "/") + req.URL.Path
+	req.Host = base.Host
+	return t.transport.RoundTrip(req)
 }
 
 func DefaultOptions() *Options {
@@ -55,6 +205,7 @@ func Run(url string, options *Options) (*common.Result, error) {
 		log.SetLevel(log.DebugLevel)
 	}
 
+	options.redactSecrets()
 	log.Debug("Running rescope", "target", url, "options", options)
 
 	platform, err := IdentifyPlatform(url, options)
@@ -62,21 +213,278 @@ func Run(url string, options *Options) (*common.Result, error) {
 		return nil, errors.Wrap(err, "unsupported or invalid URL")
 	}
 
-	result, err := platform.Run(url, options.Client)
+	if options.StrictAuth {
+		if err := verifyProgramAuth(platform, url, options); err != nil {
+			return nil, err
+		}
+	}
+
+	result, err := platform.Run(url, options.httpClient())
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to run platform")
 	}
 
+	normalize.Result(result)
+	if len(result.Unparsed) > 0 {
+		log.Debug("Skipped unparseable scope entries", "target", url, "entries", result.Unparsed)
+	}
+	result.Assets = listedAssets(result.Assets, result.InScope, result.OutScope)
+	result.Derived = unlistedAssets(result.Derived, result.InScope, result.OutScope)
+
+	if len(result.InScope) == 0 && len(result.OutScope) == 0 {
+		return nil, common.NewPlatformError(result.ProgramDetails.Platform, ErrEmptyScope)
+	}
+
 	result.ProgramDetails.FetchedAt = time.Now().Format(time.RFC3339)
 	return result, nil
 }
 
+// RunProfiles fetches the program at url with the options of each profile,
+// such as the accounts of several researchers invited to different parts of
+// the same program, and returns the union of their scopes. Profiles that fail
+// are skipped; an error is returned only if all of them fail, or if a profile
+// with StrictAuth set has its credentials rejected.
+func RunProfiles(url string, profiles []*Options) (*common.Result, error) {
+	if len(profiles) == 1 {
+		return Run(url, profiles[0])
+	}
+
+	for _, options := range profiles {
+		if !options.StrictAuth {
+			continue
+		}
+		options.redactSecrets()
+		platform, err := IdentifyPlatform(url, options)
+		if err != nil {
+			return nil, errors.Wrap(err, "unsupported or invalid URL")
+		}
+		if err := verifyProgramAuth(platform, url, options); err != nil {
+			return nil, errors.Wrapf(err, "profile %s", options.Profile)
+		}
+	}
+
+	var merged *common.Result
+	var errs []error
+	for _, options := range profiles {
+		result, err := Run(url, options)
+		if err != nil {
+			log.Warn("Failed to fetch program with profile", "target", url, "profile", options.Profile, "error", err)
+			errs = append(errs, err)
+			continue
+		}
+
+		if merged == nil {
+			merged = result
+			continue
+		}
+		merged.InScope = sliceutil.AppendUnique(merged.InScope, result.InScope...)
+		merged.OutScope = sliceutil.AppendUnique(merged.OutScope, result.OutScope...)
+		merged.Unparsed = sliceutil.AppendUnique(merged.Unparsed, result.Unparsed...)
+		merged.Assets = append(merged.Assets, result.Assets...)
+		merged.Derived = append(merged.Derived, result.Derived...)
+	}
+
+	if merged == nil {
+		return nil, stderrors.Join(errs...)
+	}
+
+	merged.Assets = listedAssets(merged.Assets, merged.InScope, merged.OutScope)
+	merged.Derived = unlistedAssets(merged.Derived, merged.InScope, merged.OutScope)
+	return merged, nil
+}
+
+// ListPrograms lists the programs visible to the configured account on each
+// platform selected by the filter, public ones included. Platforms that fail
+// are skipped, and their errors returned along with the programs of the
+// others.
+func ListPrograms(filter ProgramFilter, options *Options) ([]common.ProgramSummary, error) {
+	if options.Debug {
+		log.SetLevel(log.DebugLevel)
+	}
+
+	options.redactSecrets()
+	client := options.httpClient()
+
+	var programs []common.ProgramSummary
+	var errs []error
+	for _, platform := range programListers(options) {
+		if len(filter.Platforms) > 0 && !containsFold(filter.Platforms, platform.name) {
+			continue
+		}
+
+		if options.StrictAuth {
+			if err := verifyAuth(platform.name, options); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+
+		if filter.FollowedOnly && !platform.following {
+			log.Warn("Platform doesn't report followed programs, skipping it", "platform", platform.name)
+			continue
+		}
+
+		log.Debug("Listing programs", "platform", platform.name)
+		listed, err := platform.lister.ListPrograms(client)
+		if err != nil {
+			errs = append(errs, errors.Wrap(err, "failed to list programs"))
+			continue
+		}
+
+		for _, program := range listed {
+			if filter.Match(program) {
+				programs = append(programs, program)
+			}
+		}
+	}
+
+	return programs, stderrors.Join(errs...)
+}
+
+type namedLister struct {
+	name      string
+	lister    ProgramLister
+	following bool // whether the platform reports the programs the account follows
+}
+
+// programListers returns the platforms that can list programs, with the
+// credentials set in options
+func programListers(options *Options) []namedLister {
+	return []namedLister{
+		{"HackerOne", &hackerone.HackerOne{Auth: options.AuthHackerOne}, true},
+		{"Bugcrowd", &bugcrowd.Bugcrowd{Auth: options.AuthBugcrowd}, false},
+		{"Intigriti", &intigriti.Intigriti{Auth: options.AuthIntigriti}, true},
+		{"YesWeHack", &yeswehack.YesWeHack{Auth: options.AuthYesWeHack}, false},
+	}
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// namedChecker is a platform's AuthChecker along with the credential it
+// checks
+type namedChecker struct {
+	name    string
+	checker AuthChecker
+	token   string
+	user    string // configured account name
+}
+
+// authCheckers returns the platforms with a credential set in options
+func authCheckers(options *Options) []namedChecker {
+	checkers := []namedChecker{
+		{"HackerOne", &hackerone.HackerOne{Auth: options.AuthHackerOne}, options.AuthHackerOne, options.UserHackerOne},
+		{"Bugcrowd", &bugcrowd.Bugcrowd{Auth: options.AuthBugcrowd}, options.AuthBugcrowd, options.UserBugcrowd},
+		{"Intigriti", &intigriti.Intigriti{Auth: options.AuthIntigriti}, options.AuthIntigriti, options.UserIntigriti},
+		{"YesWeHack", &yeswehack.YesWeHack{Auth: options.AuthYesWeHack}, options.AuthYesWeHack, options.UserYesWeHack},
+	}
+
+	var configured []namedChecker
+	for _, checker := range checkers {
+		if checker.token != "" {
+			configured = append(configured, checker)
+		}
+	}
+	return configured
+}
+
+// CheckAuth verifies each credential set in options with the platform it
+// belongs to, and reports whether it is valid, invalid or expired along with
+// the account name. Platforms without a credential are skipped.
+func CheckAuth(options *Options) []common.AuthStatus {
+	if options.Debug {
+		log.SetLevel(log.DebugLevel)
+	}
+
+	options.redactSecrets()
+	client := options.httpClient()
+
+	var statuses []common.AuthStatus
+	for _, platform := range authCheckers(options) {
+		log.Debug("Checking credentials", "platform", platform.name, "profile", options.Profile)
+		account, err := platform.checker.CheckAuth(client)
+
+		status := common.AuthStatus{
+			Platform: platform.name,
+			Profile:  options.Profile,
+			Status:   common.AuthValid,
+			Account:  account,
+		}
+		if status.Account == "" {
+			status.Account = platform.user
+		}
+		if expiry, ok := common.TokenExpiry(platform.token); ok {
+			status.ExpiresAt = expiry.Format(time.RFC3339)
+		}
+
+		switch {
+		case err == nil:
+		case errors.Is(err, ErrAuthExpired):
+			status.Status = common.AuthExpired
+		case errors.Is(err, ErrAuthInvalid):
+			status.Status = common.AuthInvalid
+		default:
+			status.Status = common.AuthError
+			status.Error = err.Error()
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// verifiedTokens holds the credentials verified in strict mode, so that each
+// is checked once rather than for every program
+var verifiedTokens sync.Map
+
+// verifyProgramAuth verifies the credentials options holds for the platform
+// of the program at url
+func verifyProgramAuth(platform BugBountyProgram, url string, options *Options) error {
+	program, err := platform.ParseURL(url)
+	if err != nil {
+		return errors.Wrap(err, "unsupported or invalid URL")
+	}
+	return verifyAuth(program.Platform, options)
+}
+
+// verifyAuth checks the credential set for the named platform, if any, and
+// returns an error unless the platform accepts it
+func verifyAuth(name string, options *Options) error {
+	for _, platform := range authCheckers(options) {
+		if !strings.EqualFold(platform.name, name) {
+			continue
+		}
+
+		key := platform.name + "\x00" + platform.token
+		if _, ok := verifiedTokens.Load(key); ok {
+			return nil
+		}
+
+		if _, err := platform.checker.CheckAuth(options.httpClient()); err != nil {
+			return errors.Wrap(err, "credentials rejected in strict mode")
+		}
+		verifiedTokens.Store(key, true)
+	}
+	return nil
+}
+
+// IsBugBountyURL reports whether bugbountyURL is a program on a supported
+// platform or a security.txt file
 func IsBugBountyURL(bugbountyURL string) bool {
 	u, err := url.Parse(bugbountyURL)
 	if err != nil {
 		return false
 	}
 
+	if securitytxt.IsURL(bugbountyURL) {
+		return true
+	}
+
 	rootDomain := domainutil.GetRootDomain(u.Hostname())
 
 	switch rootDomain {
@@ -93,11 +501,15 @@ func IdentifyPlatform(bugbountyURL string, options *Options) (BugBountyProgram,
 		return nil, errors.Wrap(err, "failed to parse URL")
 	}
 
+	if securitytxt.IsURL(bugbountyURL) {
+		return &securitytxt.SecurityTxt{Key: options.SecurityTxtKey}, nil
+	}
+
 	rootDomain := domainutil.GetRootDomain(u.Hostname())
 
 	switch rootDomain {
 	case "intigriti.com":
-		return &intigriti.Intigriti{Auth: options.AuthIntigriti}, nil
+		return &intigriti.Intigriti{Auth: options.AuthIntigriti, Strict: options.StrictAuth}, nil
 	case "hackerone.com":
 		return &hackerone.HackerOne{Auth: options.AuthHackerOne}, nil
 	case "yeswehack.com":
@@ -108,3 +520,47 @@ func IdentifyPlatform(bugbountyURL string, options *Options) (BugBountyProgram,
 		return nil, fmt.Errorf("unsupported bug bounty platform for URL: %s", bugbountyURL)
 	}
 }
+
+// listedAssets returns an asset for each in-scope and out-of-scope entry, in
+// list order. Metadata reported by the platform is kept where available.
+func listedAssets(assets []common.Asset, inScope, outScope []string) []common.Asset {
+	known := make(map[string]common.Asset)
+	for _, asset := range assets {
+		known[fmt.Sprintf("%t %s", asset.InScope, asset.Identifier)] = asset
+	}
+
+	var listed []common.Asset
+	add := func(items []string, isInScope bool) {
+		for _, item := range items {
+			asset, ok := known[fmt.Sprintf("%t %s", isInScope, item)]
+			if !ok {
+				asset = normalize.Assets([]common.Asset{{Identifier: item, InScope: isInScope}})[0]
+			}
+			listed = append(listed, asset)
+		}
+	}
+
+	add(inScope, true)
+	add(outScope, false)
+	return listed
+}
+
+// unlistedAssets removes duplicates and assets already listed in any of the
+// given scope lists
+func unlistedAssets(assets []common.Asset, lists ...[]string) []common.Asset {
+	seen := make(map[string]bool)
+	for _, list := range lists {
+		for _, item := range list {
+			seen[item] = true
+		}
+	}
+
+	var unlisted []common.Asset
+	for _, asset := range assets {
+		if !seen[asset.Identifier] {
+			seen[asset.Identifier] = true
+			unlisted = append(unlisted, asset)
+		}
+	}
+	return unlisted
+}
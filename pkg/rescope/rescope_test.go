@@ -0,0 +1,166 @@
+package rescope
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/root4loot/goutils/log"
+	"github.com/root4loot/rescope/pkg/common"
+	"github.com/root4loot/rescope/pkg/redact"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListProgramsBaseURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/programs", r.URL.Path)
+		w.Write([]byte(`{"items": [
+			{"slug": "bounty", "title": "Bounty", "public": true, "bounty": true, "bounty_reward_max": 1000},
+			{"slug": "vdp", "title": "VDP", "public": true, "vdp": true}
+		], "pagination": {"page": 1, "nb_pages": 1}}`))
+	}))
+	defer server.Close()
+
+	options := DefaultOptions()
+	options.BaseURLs = map[string]string{"YesWeHack": server.URL + "/api"}
+
+	programs, err := ListPrograms(ProgramFilter{Platforms: []string{"yeswehack"}, BountyOnly: true}, options)
+	assert.NoError(t, err)
+	assert.Len(t, programs, 1)
+	assert.Equal(t, "https://yeswehack.com/programs/bounty", programs[0].URL)
+
+	programs, err = ListPrograms(ProgramFilter{Platforms: []string{"yeswehack"}, FollowedOnly: true}, options)
+	assert.NoError(t, err, "Expected platforms without followed programs to be skipped")
+	assert.Empty(t, programs)
+}
+
+func TestRunProfiles(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("Authorization") {
+		case "Bearer alice":
+			w.Write([]byte(`{"scopes":[{"scope":"shared.example.com"},{"scope":"alice.example.com"}],"out_of_scope":["blog.example.com"],"x":1}`))
+		case "Bearer bob":
+			w.Write([]byte(`{"scopes":[{"scope":"shared.example.com"},{"scope":"bob.example.com"}],"out_of_scope":[],"x":1}`))
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	profile := func(name, token string) *Options {
+		options := DefaultOptions()
+		options.Profile = name
+		options.AuthYesWeHack = token
+		options.BaseURLs = map[string]string{"yeswehack": server.URL}
+		return options
+	}
+
+	result, err := RunProfiles("https://yeswehack.com/programs/acme", []*Options{profile("alice", "alice"), profile("bob", "bob"), profile("eve", "eve")})
+	assert.NoError(t, err, "Expected a failing profile to be skipped")
+	assert.Equal(t, []string{"shared.example.com", "alice.example.com", "bob.example.com"}, result.InScope)
+	assert.Equal(t, []string{"blog.example.com"}, result.OutScope)
+	assert.Len(t, result.Assets, 4)
+
+	_, err = RunProfiles("https://yeswehack.com/programs/acme", []*Options{profile("eve", "eve")})
+	assert.ErrorIs(t, err, ErrAuthInvalid)
+
+	strict := []*Options{profile("alice", "alice"), profile("eve", "eve")}
+	for _, options := range strict {
+		options.StrictAuth = true
+	}
+	result, err = RunProfiles("https://yeswehack.com/programs/acme", strict)
+	assert.ErrorIs(t, err, ErrAuthInvalid, "Expected strict mode to fail rather than skip a rejected profile")
+	assert.Nil(t, result)
+}
+
+func TestRunRedactsLogs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	log.Init("rescope")
+	var output bytes.Buffer
+	logger := log.WithFields(nil).Logger
+	logger.SetOutput(&output)
+	defer logger.SetOutput(os.Stderr)
+
+	options := DefaultOptions()
+	options.AuthYesWeHack = "library-secret-token"
+	options.BaseURLs = map[string]string{"yeswehack": server.URL}
+
+	_, err := Run("https://yeswehack.com/programs/acme", options)
+	assert.Error(t, err)
+
+	log.Warn("Request failed", "token", options.AuthYesWeHack)
+	assert.NotContains(t, output.String(), "library-secret-token", "Expected library log output to be redacted without the CLI")
+	assert.Contains(t, output.String(), redact.Mask)
+}
+
+// jwt returns an unsigned JWT expiring at exp
+func jwt(exp time.Time) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"exp":%d}`, exp.Unix())))
+	return "e30." + payload + ".sig"
+}
+
+func TestCheckAuth(t *testing.T) {
+	valid := jwt(time.Now().Add(time.Hour))
+	expired := jwt(time.Now().Add(-time.Hour))
+
+	programRequests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/user" {
+			programRequests++
+			w.Write([]byte(`{"scopes":[{"scope":"acme.example.com"}],"out_of_scope":[],"x":1}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer "+valid {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"username": "alice"}`))
+	}))
+	defer server.Close()
+
+	options := func(token string) *Options {
+		options := DefaultOptions()
+		options.AuthYesWeHack = token
+		options.UserYesWeHack = "configured"
+		options.BaseURLs = map[string]string{"yeswehack": server.URL}
+		return options
+	}
+
+	statuses := CheckAuth(options(valid))
+	assert.Len(t, statuses, 1)
+	assert.Equal(t, common.AuthValid, statuses[0].Status)
+	assert.Equal(t, "alice", statuses[0].Account, "Expected the account name reported by the platform")
+	assert.NotEmpty(t, statuses[0].ExpiresAt)
+
+	statuses = CheckAuth(options(expired))
+	assert.Equal(t, common.AuthExpired, statuses[0].Status)
+	assert.Equal(t, "configured", statuses[0].Account, "Expected the configured account name if the platform reports none")
+
+	statuses = CheckAuth(options("opaque"))
+	assert.Equal(t, common.AuthInvalid, statuses[0].Status)
+
+	assert.Empty(t, CheckAuth(DefaultOptions()), "Expected platforms without a token to be skipped")
+
+	strict := options(expired)
+	strict.StrictAuth = true
+	_, err := Run("https://yeswehack.com/programs/acme", strict)
+	assert.ErrorIs(t, err, ErrAuthExpired)
+	assert.ErrorIs(t, err, ErrAuthInvalid, "Expected expired credentials to also be invalid")
+	assert.Equal(t, 0, programRequests, "Expected strict mode to fail before fetching the program")
+
+	strict = options(valid)
+	strict.StrictAuth = true
+	_, err = Run("https://yeswehack.com/programs/acme", strict)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, programRequests)
+}
@@ -0,0 +1,310 @@
+// Package scopefile reads and writes scope files, a versioned YAML or JSON
+// format describing one or more programs and their scope along with asset
+// metadata. A scope file maps onto common.Result, so custom scopes such as
+// pentest or red team engagements can be handled like fetched programs.
+package scopefile
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/root4loot/rescope/pkg/common"
+	"github.com/root4loot/rescope/pkg/normalize"
+	"gopkg.in/yaml.v3"
+)
+
+// Version is the latest scope file version, and the one written by rescope
+const Version = 1
+
+// Schema is the JSON Schema of the scope file format
+//
+//go:embed schema.json
+var Schema []byte
+
+type File struct {
+	Schema   string    `json:"$schema,omitempty" yaml:"$schema,omitempty"`
+	Version  int       `json:"version" yaml:"version"`
+	Programs []Program `json:"programs" yaml:"programs"`
+}
+
+type Program struct {
+	Name      string   `json:"name,omitempty" yaml:"name,omitempty"`
+	Platform  string   `json:"platform,omitempty" yaml:"platform,omitempty"`
+	Business  string   `json:"business,omitempty" yaml:"business,omitempty"`
+	URL       string   `json:"url,omitempty" yaml:"url,omitempty"`
+	PolicyURL string   `json:"policy_url,omitempty" yaml:"policy_url,omitempty"`
+	FetchedAt string   `json:"fetched_at,omitempty" yaml:"fetched_at,omitempty"`
+	InScope   []Target `json:"in_scope,omitempty" yaml:"in_scope,omitempty"`
+	OutScope  []Target `json:"out_scope,omitempty" yaml:"out_scope,omitempty"`
+}
+
+// Target is a scope entry with its metadata. A target without metadata may
+// be written as a plain string.
+type Target struct {
+	Target         string `json:"target" yaml:"target"`
+	Category       string `json:"category,omitempty" yaml:"category,omitempty"`
+	BountyEligible *bool  `json:"bounty_eligible,omitempty" yaml:"bounty_eligible,omitempty"`
+	MaxSeverity    string `json:"max_severity,omitempty" yaml:"max_severity,omitempty"`
+	Ports          []int  `json:"ports,omitempty" yaml:"ports,omitempty"` // the target is listed once per port
+	Notes          string `json:"notes,omitempty" yaml:"notes,omitempty"`
+}
+
+// target avoids recursion when (un)marshaling the full form of Target
+type target Target
+
+func (t *Target) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte(`"`)) {
+		return json.Unmarshal(data, &t.Target)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode((*target)(t))
+}
+
+func (t Target) MarshalJSON() ([]byte, error) {
+	if t.plain() {
+		return json.Marshal(t.Target)
+	}
+	return json.Marshal(target(t))
+}
+
+// targetFields are the fields of the full form of Target. Node.Decode doesn't
+// inherit KnownFields from the decoder, so they're checked here.
+var targetFields = map[string]bool{"target": true, "category": true, "bounty_eligible": true, "max_severity": true, "ports": true, "notes": true}
+
+func (t *Target) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&t.Target)
+	}
+
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i < len(node.Content); i += 2 {
+			if key := node.Content[i]; !targetFields[key.Value] {
+				return fmt.Errorf("line %d: field %s not found in target", key.Line, key.Value)
+			}
+		}
+	}
+	return node.Decode((*target)(t))
+}
+
+func (t Target) MarshalYAML() (interface{}, error) {
+	if t.plain() {
+		return t.Target, nil
+	}
+	return target(t), nil
+}
+
+// plain reports whether t has no metadata
+func (t Target) plain() bool {
+	return t.Category == "" && t.BountyEligible == nil && t.MaxSeverity == "" && len(t.Ports) == 0 && t.Notes == ""
+}
+
+// Detect reports whether data looks like a scope file rather than a plain
+// list of entries
+func Detect(data []byte) bool {
+	var fields map[string]interface{}
+	if err := yaml.Unmarshal(data, &fields); err != nil {
+		return false
+	}
+
+	_, hasVersion := fields["version"]
+	_, hasPrograms := fields["programs"]
+	return hasVersion || hasPrograms
+}
+
+// Parse reads a scope file in YAML or JSON. Unknown fields are rejected so
+// that typos don't silently change the scope.
+func Parse(data []byte) (*File, error) {
+	var file File
+
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&file); err != nil {
+			return nil, fmt.Errorf("failed to parse scope file: %w", err)
+		}
+	} else {
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&file); err != nil {
+			return nil, fmt.Errorf("failed to parse scope file: %w", err)
+		}
+	}
+
+	switch {
+	case file.Version == 0:
+		return nil, fmt.Errorf("scope file has no version")
+	case file.Version > Version:
+		return nil, fmt.Errorf("unsupported scope file version %d (latest supported is %d)", file.Version, Version)
+	}
+
+	for _, program := range file.Programs {
+		for _, target := range append(program.InScope, program.OutScope...) {
+			if strings.TrimSpace(target.Target) == "" {
+				return nil, fmt.Errorf("program %q has an empty target", program.Name)
+			}
+		}
+	}
+
+	return &file, nil
+}
+
+// Results returns a normalized result per program in file. Targets that
+// can't be parsed are listed in Unparsed, as with fetched programs.
+func Results(file *File) ([]*common.Result, error) {
+	var results []*common.Result
+
+	for _, program := range file.Programs {
+		result := &common.Result{
+			ProgramDetails: common.BugBountyProgram{
+				InputURL:    program.URL,
+				Platform:    program.Platform,
+				Business:    program.Business,
+				ProgramName: program.Name,
+				PolicyURL:   program.PolicyURL,
+				FetchedAt:   program.FetchedAt,
+			},
+			FetchedAt: program.FetchedAt,
+		}
+
+		add := func(list *[]string, targets []Target, inScope bool) error {
+			for _, target := range targets {
+				identifiers, err := target.identifiers()
+				if err != nil {
+					return fmt.Errorf("program %q: %w", program.Name, err)
+				}
+
+				*list = append(*list, identifiers...)
+				for _, identifier := range identifiers {
+					result.Assets = append(result.Assets, common.Asset{
+						Identifier:     identifier,
+						Category:       target.Category,
+						InScope:        inScope,
+						BountyEligible: target.BountyEligible,
+						MaxSeverity:    target.MaxSeverity,
+						Notes:          target.Notes,
+					})
+				}
+			}
+			return nil
+		}
+
+		if err := add(&result.InScope, program.InScope, true); err != nil {
+			return nil, err
+		}
+		if err := add(&result.OutScope, program.OutScope, false); err != nil {
+			return nil, err
+		}
+
+		normalize.Result(result)
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// identifiers returns the scope entries of t, one per port if ports are given
+func (t Target) identifiers() ([]string, error) {
+	if len(t.Ports) == 0 {
+		return []string{t.Target}, nil
+	}
+
+	entry, err := normalize.Parse(t.Target)
+	if err != nil {
+		return nil, err
+	}
+	if entry.Kind == normalize.KindCIDR || entry.Port != "" {
+		return nil, fmt.Errorf("ports can't be given for %q, only for hosts and URLs without a port", t.Target)
+	}
+	if entry.Kind == normalize.KindIP {
+		// an IP with a port is a host, as when written as ip:port
+		entry.Kind = normalize.KindDomain
+		if strings.Contains(entry.Host, ":") {
+			entry.Host = "[" + entry.Host + "]"
+		}
+	}
+
+	var identifiers []string
+	for _, port := range t.Ports {
+		if port < 1 || port > 65535 {
+			return nil, fmt.Errorf("invalid port %d for %q", port, t.Target)
+		}
+		entry.Port = strconv.Itoa(port)
+		identifiers = append(identifiers, entry.String())
+	}
+	return identifiers, nil
+}
+
+// FromResults returns a scope file describing programs. Asset metadata is
+// kept for entries that have it.
+func FromResults(programs []*common.Result) *File {
+	file := &File{Version: Version, Programs: []Program{}}
+
+	for _, result := range programs {
+		details := result.ProgramDetails
+		fetchedAt := details.FetchedAt
+		if fetchedAt == "" {
+			fetchedAt = result.FetchedAt
+		}
+
+		file.Programs = append(file.Programs, Program{
+			Name:      details.ProgramName,
+			Platform:  details.Platform,
+			Business:  details.Business,
+			URL:       details.InputURL,
+			PolicyURL: details.PolicyURL,
+			FetchedAt: fetchedAt,
+			InScope:   targets(result, result.InScope, true),
+			OutScope:  targets(result, result.OutScope, false),
+		})
+	}
+
+	return file
+}
+
+func targets(result *common.Result, items []string, inScope bool) []Target {
+	assets := make(map[string]common.Asset)
+	for _, asset := range result.Assets {
+		if asset.InScope == inScope {
+			assets[asset.Identifier] = asset
+		}
+	}
+
+	var targets []Target
+	for _, item := range items {
+		target := Target{Target: item}
+		if asset, ok := assets[item]; ok {
+			target.Category = asset.Category
+			target.BountyEligible = asset.BountyEligible
+			target.MaxSeverity = asset.MaxSeverity
+			target.Notes = asset.Notes
+		}
+		targets = append(targets, target)
+	}
+	return targets
+}
+
+// EncodeYAML returns file as YAML
+func EncodeYAML(file *File) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(file); err != nil {
+		return nil, fmt.Errorf("failed to serialize scope file to YAML: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// EncodeJSON returns file as indented JSON
+func EncodeJSON(file *File) ([]byte, error) {
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize scope file to JSON: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,112 @@
+package scopefile
+
+import (
+	"testing"
+
+	"github.com/root4loot/rescope/pkg/common"
+	"github.com/stretchr/testify/assert"
+)
+
+const yamlFile = `version: 1
+programs:
+  - name: Acme Internal
+    policy_url: https://wiki.acme.internal/pentest
+    in_scope:
+      - "*.acme.com"
+      - target: 10.0.0.1
+        ports: [8080, 8443]
+        notes: staging load balancer
+      - target: api.acme.com
+        category: api
+        bounty_eligible: false
+    out_scope:
+      - admin.acme.com
+`
+
+func TestParse(t *testing.T) {
+	assert.True(t, Detect([]byte(yamlFile)))
+	assert.False(t, Detect([]byte("*.acme.com\napi.acme.com")))
+	assert.False(t, Detect([]byte(`{"target":{"scope":{}}}`)))
+
+	file, err := Parse([]byte(yamlFile))
+	assert.NoError(t, err)
+
+	results, err := Results(file)
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+
+	result := results[0]
+	assert.Equal(t, "Acme Internal", result.ProgramDetails.ProgramName)
+	assert.Equal(t, []string{"*.acme.com", "10.0.0.1:8080", "10.0.0.1:8443", "api.acme.com"}, result.InScope)
+	assert.Equal(t, []string{"admin.acme.com"}, result.OutScope)
+	assert.Len(t, result.Assets, 5)
+	assert.Equal(t, "staging load balancer", result.Assets[1].Notes)
+	assert.Equal(t, "api", result.Assets[3].Category)
+	assert.False(t, *result.Assets[3].BountyEligible)
+
+	json := `{"version":1,"programs":[{"name":"Acme","in_scope":["acme.com",{"target":"api.acme.com","notes":"v2 only"}]}]}`
+	file, err = Parse([]byte(json))
+	assert.NoError(t, err)
+	assert.Equal(t, "v2 only", file.Programs[0].InScope[1].Notes)
+
+	invalid := []string{
+		"programs: []",
+		"version: 2\nprograms: []",
+		"version: 1\nprograms:\n  - nmae: typo",
+		"version: 1\nprograms:\n  - in_scope:\n      - target: acme.com\n        port: 80",
+		`{"version":1,"programs":[{"in_scope":[{"target":"acme.com","note":"typo"}]}]}`,
+	}
+	for _, data := range invalid {
+		_, err := Parse([]byte(data))
+		assert.Error(t, err, "Expected error for %q", data)
+	}
+
+	file, err = Parse([]byte("version: 1\nprograms:\n  - in_scope:\n      - target: 10.0.0.0/24\n        ports: [80]"))
+	assert.NoError(t, err)
+	_, err = Results(file)
+	assert.Error(t, err, "Expected error for ports on a CIDR")
+}
+
+func TestRoundTrip(t *testing.T) {
+	eligible := true
+	program := &common.Result{
+		ProgramDetails: common.BugBountyProgram{ProgramName: "Example", Platform: "HackerOne", PolicyURL: "https://hackerone.com/example"},
+		InScope:        []string{"*.example.com", "api.example.com"},
+		OutScope:       []string{"admin.example.com"},
+		Assets: []common.Asset{
+			{Identifier: "api.example.com", InScope: true, Category: "URL", BountyEligible: &eligible},
+		},
+	}
+
+	file := FromResults([]*common.Result{program})
+
+	data, err := EncodeYAML(file)
+	assert.NoError(t, err)
+	assert.Equal(t, `version: 1
+programs:
+  - name: Example
+    platform: HackerOne
+    policy_url: https://hackerone.com/example
+    in_scope:
+      - '*.example.com'
+      - target: api.example.com
+        category: URL
+        bounty_eligible: true
+    out_scope:
+      - admin.example.com
+`, string(data))
+
+	for _, encode := range []func(*File) ([]byte, error){EncodeYAML, EncodeJSON} {
+		data, err := encode(file)
+		assert.NoError(t, err)
+
+		parsed, err := Parse(data)
+		assert.NoError(t, err)
+
+		results, err := Results(parsed)
+		assert.NoError(t, err)
+		assert.Equal(t, program.InScope, results[0].InScope)
+		assert.Equal(t, program.OutScope, results[0].OutScope)
+		assert.Equal(t, program.ProgramDetails, results[0].ProgramDetails)
+	}
+}
@@ -0,0 +1,90 @@
+// Package trace records the HTTP requests sent to the platforms and their
+// responses, with credentials redacted, for debugging and bug reports.
+package trace
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"sync"
+	"time"
+
+	"github.com/root4loot/rescope/pkg/redact"
+)
+
+// Transport writes each request and its response to Out before returning the
+// response. Sensitive headers are masked, as are credentials found in URLs and
+// bodies.
+type Transport struct {
+	Base http.RoundTripper // http.DefaultTransport if nil
+	Out  io.Writer
+
+	mu sync.Mutex
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	requestDump, err := dumpRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, err := base.RoundTrip(req)
+	elapsed := time.Since(start).Round(time.Millisecond)
+
+	var responseDump []byte
+	if err == nil {
+		responseDump, err = dumpResponse(resp)
+		if err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+	} else {
+		responseDump = []byte("error: " + err.Error() + "\n")
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fmt.Fprintf(t.Out, "### %s %s %s (%s)\n\n%s\n\n%s\n\n",
+		start.Format(time.RFC3339), req.Method, redact.String(req.URL.String()), elapsed,
+		redact.String(string(requestDump)), redact.String(string(responseDump)))
+
+	return resp, err
+}
+
+// dumpRequest dumps req with sensitive headers masked. The body is restored
+// so the request can still be sent.
+func dumpRequest(req *http.Request) ([]byte, error) {
+	dumped := req.Clone(req.Context())
+	dumped.Header = redact.Header(req.Header)
+
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		dumped.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	return httputil.DumpRequestOut(dumped, true)
+}
+
+// dumpResponse dumps resp with sensitive headers masked. The body is
+// restored so the response can still be read.
+func dumpResponse(resp *http.Response) ([]byte, error) {
+	dumped := *resp
+	dumped.Header = redact.Header(resp.Header)
+
+	dump, err := httputil.DumpResponse(&dumped, true)
+	resp.Body = dumped.Body
+	return dump, err
+}
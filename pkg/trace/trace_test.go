@@ -0,0 +1,42 @@
+package trace
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, `{"query":"programs"}`, string(body), "Expected the request body to be sent")
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"), "Expected the real header to be sent")
+
+		http.SetCookie(w, &http.Cookie{Name: "__Host-session", Value: "secret-session"})
+		w.Write([]byte(`{"csrf_token":"secret-csrf","programs":["acme"]}`))
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	client := &http.Client{Transport: &Transport{Out: &out}}
+
+	req, _ := http.NewRequest("POST", server.URL+"/graphql", strings.NewReader(`{"query":"programs"}`))
+	req.Header.Set("Authorization", "Bearer secret")
+
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Contains(t, string(body), "secret-csrf", "Expected the response body to be readable")
+
+	trace := out.String()
+	assert.Contains(t, trace, "POST "+server.URL+"/graphql")
+	assert.Contains(t, trace, `{"query":"programs"}`)
+	assert.Contains(t, trace, `"programs":["acme"]`)
+	assert.NotContains(t, trace, "secret")
+}